@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/alert"
+	"github.com/imedgar/rain-alert/internal/config"
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/platform/database"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// thresholdFlag collects repeated name=value thresholds.
+type thresholdFlag map[string]int
+
+func (f thresholdFlag) String() string {
+	return fmt.Sprint(map[string]int(f))
+}
+
+func (f thresholdFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	th, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("threshold %s: %w", name, err)
+	}
+	f[name] = th
+	return nil
+}
+
+type backtestResult struct {
+	Location  string        `json:"location"`
+	Snapshots int           `json:"snapshots"`
+	Fired     []alert.Fired `json:"fired"`
+}
+
+// backtest replays stored forecast snapshots, or recorded WeatherResponse
+// files, through the next-hour alerts with candidate thresholds and rules,
+// and reports the notifications that would have been sent.
+func backtest(ctx context.Context, c *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	dir := fs.String("dir", "", "directory of recorded WeatherResponse JSON files, with a subdirectory per location when several are backtested; stored snapshots are replayed otherwise")
+	days := fs.Int("days", 7, "days of stored snapshots to replay")
+	name := fs.String("location", "", "only backtest this location")
+	rulesFile := fs.String("rules", c.RulesFile, "YAML file of candidate alert rules, instead of the alert_rules table")
+	asJSON := fs.Bool("json", false, "print the notifications as JSON")
+	verbose := fs.Bool("v", false, "log every decision")
+	candidates := thresholdFlag{}
+	fs.Var(candidates, "threshold", "candidate `name=value` threshold, overriding the configured ones; may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	locations, err := c.Locations()
+	if err != nil {
+		return err
+	}
+	if *name != "" {
+		locations = filterLocations(locations, *name)
+		if len(locations) == 0 {
+			return fmt.Errorf("unknown location %q", *name)
+		}
+	}
+
+	defaults, err := db.GetThresholds(ctx)
+	if err != nil {
+		return fmt.Errorf("getting thresholds: %w", err)
+	}
+
+	rules, err := loadRules(ctx, *rulesFile, db, commonThresholds(locations, defaults, candidates))
+	if err != nil {
+		return fmt.Errorf("loading alert rules: %w", err)
+	}
+	alerter := &alert.Alerter{Rules: rules}
+
+	var results []backtestResult
+	for _, loc := range locations {
+		var snapshots []weather.Snapshot
+		if *dir != "" {
+			locDir := *dir
+			if len(locations) > 1 {
+				locDir = filepath.Join(locDir, loc.Name)
+			}
+			snapshots, err = weather.LoadSnapshots(locDir)
+		} else {
+			snapshots, err = storedSnapshots(ctx, db, loc.Name, time.Now().AddDate(0, 0, -*days))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: loading snapshots: %w", loc.Name, err)
+		}
+
+		thresholds := loc.ThresholdsFrom(defaults)
+		maps.Copy(thresholds, candidates)
+		fired, err := alerter.Backtest(ctx, loc, thresholds, snapshots)
+		if err != nil {
+			return fmt.Errorf("%s: %w", loc.Name, err)
+		}
+		results = append(results, backtestResult{Location: loc.Name, Snapshots: len(snapshots), Fired: fired})
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	return printBacktest(os.Stdout, results)
+}
+
+func filterLocations(locations []location.Location, name string) []location.Location {
+	for _, loc := range locations {
+		if loc.Name == name {
+			return []location.Location{loc}
+		}
+	}
+	return nil
+}
+
+func storedSnapshots(ctx context.Context, db *database.DB, loc string, since time.Time) ([]weather.Snapshot, error) {
+	stored, err := db.GetSnapshots(ctx, loc, since)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]weather.Snapshot, 0, len(stored))
+	for _, s := range stored {
+		snapshot, err := weather.ParseSnapshot(s.Body, s.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot at %s: %w", s.CreatedAt.Format(time.RFC3339), err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// printBacktest writes, for each location, the notifications that would have
+// been sent and how many of each kind.
+func printBacktest(out io.Writer, results []backtestResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		fmt.Fprintf(w, "%s: %d notifications from %d snapshots\n", r.Location, len(r.Fired), r.Snapshots)
+
+		counts := map[string]int{}
+		for _, f := range r.Fired {
+			fmt.Fprintf(w, "  %s\t%s\t%s\tfor %s\tstate %d\t%s\n", f.Time.Format(weather.TimeLayout), f.Kind, f.Report, f.Hour, f.State, f.Severity)
+			counts[f.Kind]++
+		}
+		for _, kind := range slices.Sorted(maps.Keys(counts)) {
+			fmt.Fprintf(w, "  %s: %d\n", kind, counts[kind])
+		}
+	}
+	return w.Flush()
+}
@@ -3,14 +3,25 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/imedgar/rain-alert/internal/alert"
 	"github.com/imedgar/rain-alert/internal/config"
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/nowcast"
 	"github.com/imedgar/rain-alert/internal/platform/database"
+	"github.com/imedgar/rain-alert/internal/platform/httpclient"
 	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/rule"
 	"github.com/imedgar/rain-alert/internal/weather"
 
 	_ "github.com/tursodatabase/libsql-client-go/libsql"
@@ -23,7 +34,16 @@ func main() {
 }
 
 func run() error {
-	ctx := context.Background()
+	dryRun := flag.Bool("dry-run", false, "check without sending or writing anything, and print an explanation of each decision")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-dry-run]\n       %s backtest [flags]\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Cancelled on SIGINT and SIGTERM, so pending requests and queries stop.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	c, err := config.NewConfig(ctx)
 	if err != nil {
@@ -36,19 +56,123 @@ func run() error {
 	}
 	defer db.Close()
 
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		return fmt.Errorf("pinging database: %w", err)
 	}
 
 	dbPlatform := database.New(db)
-	weatherAPI := weather.NewAPI(http.DefaultClient, "http://api.weatherapi.com/v1/forecast.json", c.WeatherApiKey)
-	ntfyClient := ntfy.New(http.DefaultClient, "https://ntfy.sh", c.PushNotificationTopic)
+	if flag.Arg(0) == "backtest" {
+		return backtest(ctx, c, dbPlatform, flag.Args()[1:])
+	}
 
-	alerter := alert.NewAlerter(weatherAPI, dbPlatform, ntfyClient)
+	httpClient := httpclient.New(&http.Client{}, c.HTTPTimeout, c.HTTPRetries)
+	weatherAPI := weather.NewAPI(httpClient, "http://api.weatherapi.com/v1/forecast.json", c.WeatherApiKey)
+	weatherAPI.SearchURL = "http://api.weatherapi.com/v1/search.json"
+	ntfyClient := ntfy.New(httpClient, c.NtfyURL, c.PushNotificationTopic)
+	ntfyClient.Token = c.NtfyToken
+	ntfyClient.Username = c.NtfyUsername
+	ntfyClient.Password = c.NtfyPassword
 
-	if err := alerter.CheckAndAlert(c.Location, c.Timezone); err != nil {
+	messages := &message.Catalog{
+		DefaultSet:    c.MessageSet,
+		DefaultLocale: c.Locale,
+		File:          c.MessageTemplatesFile,
+		Store:         dbPlatform,
+		NewPicker: func() (message.Picker, error) {
+			return message.NewPicker(c.MessageStrategy, c.MessageNoRepeat, dbPlatform)
+		},
+	}
+	if _, err := messages.Get(ctx, "", ""); err != nil {
+		return fmt.Errorf("loading message templates: %w", err)
+	}
+
+	locations, err := c.Locations()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	defaults, err := dbPlatform.GetThresholds(ctx)
+	if err != nil {
+		return fmt.Errorf("getting thresholds: %w", err)
+	}
+	rules, err := loadRules(ctx, c.RulesFile, dbPlatform, commonThresholds(locations, defaults, nil))
+	if err != nil {
+		return fmt.Errorf("loading alert rules: %w", err)
+	}
+
+	alerter := alert.NewAlerter(weatherAPI, dbPlatform, ntfyClient, messages)
+	alerter.RadarURL = c.RadarURL
+	alerter.IconURL = c.IconURL
+	alerter.Rules = rules
+	alerter.RecordSnapshots = c.RecordSnapshots
+	if c.NowcastProvider != "" {
+		if alerter.Nowcast, err = nowcast.New(c.NowcastProvider, httpClient); err != nil {
+			return err
+		}
+	}
+	if *dryRun {
+		dbPlatform.ReadOnly = true
+		alerter.Explanation = &alert.Explanation{}
+	}
+
+	err = alerter.Run(ctx, locations)
+	if *dryRun {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(alerter.Explanation.Steps()); err != nil {
+			return fmt.Errorf("writing explanation: %w", err)
+		}
+	}
+	return err
+}
+
+// loadRules compiles the alert rules from the rules file if set, otherwise
+// from the alert_rules table, checking that they only refer to the given
+// thresholds.
+func loadRules(ctx context.Context, file string, db *database.DB, thresholds map[string]int) ([]*rule.Rule, error) {
+	if file != "" {
+		defs, err := rule.LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return rule.Compile(defs, thresholds)
+	}
+
+	rows, err := db.GetAlertRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]rule.Definition, 0, len(rows))
+	for _, r := range rows {
+		defs = append(defs, rule.Definition{
+			Name:     r.Name,
+			When:     r.Condition,
+			Exit:     r.Exit,
+			Repeat:   r.Repeat,
+			Severity: r.Severity,
+			Cooldown: r.Cooldown,
+			Title:    r.Title,
+			Message:  r.Message,
+		})
+	}
+	return rule.Compile(defs, thresholds)
+}
+
+// commonThresholds returns the thresholds configured for every location,
+// with the overrides applied, which are the ones rules can refer to.
+func commonThresholds(locations []location.Location, defaults, overrides map[string]int) map[string]int {
+	var common map[string]int
+	for _, loc := range locations {
+		thresholds := loc.ThresholdsFrom(defaults)
+		maps.Copy(thresholds, overrides)
+		if common == nil {
+			common = thresholds
+			continue
+		}
+		maps.DeleteFunc(common, func(name string, _ int) bool {
+			_, ok := thresholds[name]
+			return !ok
+		})
+	}
+	return common
 }
@@ -0,0 +1,56 @@
+package alert
+
+import (
+	"cmp"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// defaultAirQualityCooldown applies unless airQualityCooldown (minutes) is
+// configured. Air quality changes slowly, so it is longer than rain's.
+const defaultAirQualityCooldown = 6 * time.Hour
+
+var airQualityTags = []string{"mask", "robot"}
+
+// pollutedAir reports whether the air quality reaches aqiThreshold (US EPA
+// index, unhealthy by default) or any of pm25Threshold, pm10Threshold and
+// o3Threshold (µg/m³). Unset concentration thresholds are ignored.
+func pollutedAir(air weather.AirQuality, thresholds map[string]int) bool {
+	exceeds := func(v float64, key string) bool {
+		return thresholds[key] > 0 && v >= float64(thresholds[key])
+	}
+	return air.USEPAIndex >= cmp.Or(thresholds["aqiThreshold"], weather.AQIUnhealthy) ||
+		exceeds(air.PM25, "pm25Threshold") || exceeds(air.PM10, "pm10Threshold") || exceeds(air.O3, "o3Threshold")
+}
+
+func airQualitySeverity(index int) Severity {
+	switch {
+	case index >= weather.AQIHazardous:
+		return SeverityHigh
+	case index >= weather.AQIVeryUnhealthy:
+		return SeverityModerate
+	default:
+		return SeverityLow
+	}
+}
+
+// airQualityAlert alerts of poor air quality in the next hour, for locations
+// that opted in. Within the cooldown it is sent again only if the index
+// worsens.
+func airQualityAlert(loc location.Location, hour weather.Hour, thresholds map[string]int) (hourAlert, bool) {
+	if !loc.AirQuality || hour.AirQuality == nil || !pollutedAir(*hour.AirQuality, thresholds) {
+		return hourAlert{}, false
+	}
+
+	return hourAlert{
+		kind:     kindAirQuality,
+		report:   message.ReportAirQuality,
+		tags:     airQualityTags,
+		severity: airQualitySeverity(hour.AirQuality.USEPAIndex),
+		state:    hour.AirQuality.USEPAIndex,
+		cooldown: cooldownFrom(thresholds, "airQualityCooldown", defaultAirQualityCooldown),
+	}, true
+}
@@ -1,58 +1,448 @@
 package alert
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/nowcast"
 	"github.com/imedgar/rain-alert/internal/platform/database"
 	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/rule"
 	"github.com/imedgar/rain-alert/internal/weather"
 )
 
+// Notifier delivers messages. *ntfy.Client is the default implementation.
+type Notifier interface {
+	Send(ctx context.Context, msg ntfy.Message) error
+}
+
+// Kinds of next-hour alerts, each with its own notification history.
+const (
+	kindRain       = "rain"
+	kindWind       = "wind"
+	kindAirQuality = "air-quality"
+	kindUV         = "uv"
+	kindHeat       = "heat"
+)
+
+// cooldown is how long a notification can suppress the next of its kind.
+const cooldown = time.Hour
+
+// runInterval is how often the alerts run, as scheduled.
+const runInterval = time.Hour
+
 type Alerter struct {
-	Weather *weather.API
-	DB      *database.DB
-	Ntfy    *ntfy.Client
+	Weather  *weather.API
+	DB       *database.DB
+	Notifier Notifier
+	Messages *message.Catalog
+	// Store keeps the next-hour alerts' history and states, in DB unless
+	// backtesting.
+	Store Store
+
+	// RadarURL, when set, is opened on tap and offered as a "View radar" action.
+	RadarURL string
+	// IconURL, when set, is shown as the notification icon.
+	IconURL string
+	// Rules are user-defined alerts about the next hour. One named rule.Rain
+	// replaces the condition of the built-in rain alert.
+	Rules []*rule.Rule
+	// Nowcast, when set, provides sub-hourly precipitation forecasts to alert
+	// of rain shortly before it starts.
+	Nowcast nowcast.Provider
+	// RecordSnapshots stores every forecast fetched, to be replayed by
+	// Backtest.
+	RecordSnapshots bool
+	// Explanation, when set, makes a dry run: nothing is sent and every
+	// decision is recorded in it. The database should be read-only too.
+	Explanation *Explanation
+
+	// fired, when set, is told of each next-hour notification sent, for
+	// backtests.
+	fired func(Fired)
 }
 
-func NewAlerter(weather *weather.API, db *database.DB, ntfy *ntfy.Client) *Alerter {
-	return &Alerter{Weather: weather, DB: db, Ntfy: ntfy}
+func NewAlerter(weather *weather.API, db *database.DB, notifier Notifier, messages *message.Catalog) *Alerter {
+	return &Alerter{Weather: weather, DB: db, Notifier: notifier, Messages: messages, Store: db}
 }
 
-func (a *Alerter) CheckAndAlert(location, timezone string) error {
-	weatherData, hour, err := a.Weather.GetNextHourForecast(location, timezone)
+// Run checks every location concurrently. A failing location does not stop
+// the others, all errors are returned together.
+func (a *Alerter) Run(ctx context.Context, locations []location.Location) error {
+	errs := make([]error, len(locations))
+
+	var wg sync.WaitGroup
+	for i, loc := range locations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := a.CheckAndAlert(ctx, loc); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", loc.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// CheckAndAlert fetches the forecast for a location once and notifies each
+// of its recipients independently.
+func (a *Alerter) CheckAndAlert(ctx context.Context, loc location.Location) error {
+	defaults, err := a.DB.GetThresholds(ctx)
+	if err != nil {
+		return fmt.Errorf("getting thresholds: %w", err)
+	}
+
+	recipients, err := a.recipients(ctx, loc, defaults)
+	if err != nil {
+		return err
+	}
+
+	query, place, err := a.resolve(ctx, loc)
+	if err != nil {
+		return err
+	}
+
+	weatherData, days, now, err := a.forecast(ctx, loc, query, recipients)
+	if err != nil {
+		return err
+	}
+	hour, err := weatherData.NextHour(now)
 	if err != nil {
 		return fmt.Errorf("getting forecast: %w", err)
 	}
+	a.explainStep(loc, nil, Step{Check: "forecast", Passed: true, Detail: fmt.Sprintf("%d days for %q at %s, next hour %s", days, query, now.Format(weather.TimeLayout), hour.Time), Hour: hour})
+
+	if a.RecordSnapshots {
+		body, err := json.Marshal(weatherData)
+		if err != nil {
+			return fmt.Errorf("encoding forecast snapshot: %w", err)
+		}
+		if err := a.DB.RecordSnapshot(ctx, loc.Name, body); err != nil {
+			return fmt.Errorf("recording forecast snapshot: %w", err)
+		}
+	}
 
-	thresholds, err := a.DB.GetThresholds()
+	var nc *nowcast.Nowcast
+	if a.Nowcast != nil {
+		lat, lon := weatherData.Location.Lat, weatherData.Location.Lon
+		if place != nil {
+			lat, lon = place.Lat, place.Lon
+		}
+		// The nowcast only adds to the hourly forecast, the other alerts are
+		// sent without it.
+		if nc, err = a.Nowcast.Nowcast(ctx, lat, lon); err != nil {
+			log.Printf("%s: getting nowcast: %v\n", loc.Name, err)
+			a.explain(loc, nil, "nowcast", false, "getting nowcast: %v", err)
+		} else {
+			a.explain(loc, nil, "nowcast", true, "%d steps of %s at %g,%g", len(nc.Steps), nc.Interval, lat, lon)
+		}
+	}
+
+	var errs []error
+	for _, r := range recipients {
+		if err := a.alertRecipient(ctx, loc, r, weatherData, hour, nc, now); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// forecast fetches the days of forecast the recipients need, and returns it
+// with the current time in the location's timezone. Outlooks need more days
+// depending on the local time, so without a configured timezone the
+// forecast is fetched again when one turns out to be due.
+func (a *Alerter) forecast(ctx context.Context, loc location.Location, query string, recipients []recipient) (*weather.WeatherResponse, int, time.Time, error) {
+	days := 1
+	if tz, err := time.LoadLocation(loc.Timezone); loc.Timezone != "" && err == nil {
+		days = forecastDays(recipients, time.Now().In(tz))
+	}
+
+	weatherData, err := a.Weather.GetForecast(ctx, query, days, loc.AirQuality)
 	if err != nil {
-		return fmt.Errorf("getting thresholds: %w", err)
+		return nil, 0, time.Time{}, fmt.Errorf("getting forecast: %w", err)
+	}
+	tz, err := a.timezone(loc, weatherData)
+	if err != nil {
+		return nil, 0, time.Time{}, err
 	}
+	now := time.Now().In(tz)
 
-	if hour.ChanceOfRain < thresholds["drizzleThreshold"] {
-		log.Printf("Chance of rain (%d%%) too low, not notifying.\n", hour.ChanceOfRain)
-		return nil
+	if needed := forecastDays(recipients, now); needed > days {
+		days = needed
+		if weatherData, err = a.Weather.GetForecast(ctx, query, days, loc.AirQuality); err != nil {
+			return nil, 0, time.Time{}, fmt.Errorf("getting forecast: %w", err)
+		}
 	}
+	return weatherData, days, now, nil
+}
+
+// alertRecipient runs each of the recipient's alerts independently, so one
+// failing does not keep the others from being sent. All errors are returned
+// together.
+func (a *Alerter) alertRecipient(ctx context.Context, loc location.Location, r recipient, weatherData *weather.WeatherResponse, hour *weather.Hour, nc *nowcast.Nowcast, now time.Time) error {
+	a.explainStep(loc, &r, Step{Check: "thresholds", Passed: true, Thresholds: r.thresholds})
 
-	notify, err := a.DB.ShouldNotify(thresholds)
+	var errs []error
+	quiet, isQuiet := location.ActiveQuietHours(r.quietHours, now)
+	a.explain(loc, &r, "quiet hours", !isQuiet, "quiet hours active: %t, hold: %t", isQuiet, quiet.Hold)
+	if !isQuiet {
+		errs = append(errs,
+			a.releaseHeld(ctx, loc, r),
+			a.forwardWarnings(ctx, loc, r, weatherData, now),
+			a.alertCommutes(ctx, loc, r, weatherData, now),
+			a.sendDigest(ctx, loc, r, weatherData, now),
+			a.sendOutlook(ctx, loc, r, weatherData, now),
+		)
+	}
+
+	if r.disableNextHour {
+		a.explain(loc, &r, "next hour", false, "next-hour alerts disabled")
+		return errors.Join(errs...)
+	}
+
+	errs = append(errs, a.alertHour(ctx, loc, r, weatherData, hour, quiet, isQuiet))
+	if nc != nil {
+		errs = append(errs, a.alertNowcast(ctx, loc, r, nc, now))
+	}
+	return errors.Join(errs...)
+}
+
+// alertHour sends the alerts about the next hour: precipitation, the other
+// built-in alerts and the rules.
+func (a *Alerter) alertHour(ctx context.Context, loc location.Location, r recipient, weatherData *weather.WeatherResponse, hour *weather.Hour, quiet location.QuietHours, isQuiet bool) error {
+	alerts, err := a.hourAlerts(loc, r, *hour)
+	errs := []error{err}
+	for _, h := range alerts {
+		errs = append(errs, a.sendHourAlert(ctx, loc, r, hour, isQuiet, h))
+	}
+	errs = append(errs, a.alertNextHour(ctx, loc, r, weatherData, hour, quiet, isQuiet))
+	return errors.Join(errs...)
+}
+
+// rainAlert evaluates the rain rule on the next hour and, when it matches or
+// has an exit condition, classifies the precipitation. Its state is the
+// chance of precipitation.
+func (a *Alerter) rainAlert(loc location.Location, r recipient, hour weather.Hour) (h hourAlert, match bool, err error) {
+	chance := hour.Chance()
+	rain := a.rainRule(r.thresholds)
+	thresholds := a.rainThresholds(r.thresholds, rain)
+	match, err = rain.Match(hour, thresholds)
 	if err != nil {
-		return fmt.Errorf("checking notification history: %w", err)
+		return hourAlert{}, false, err
+	}
+	a.explain(loc, &r, "rain", match, "%s with chance %d%%, %g mm, %s", rain.When, chance, hour.PrecipMM, hour.Precipitation())
+	if !match {
+		log.Printf("%s/%s: %s not met (chance of precipitation %d%%), not notifying.\n", loc.Name, r.name, rain.When, chance)
+		if !rain.Hysteresis() {
+			return hourAlert{}, false, nil
+		}
 	}
 
-	if !notify {
-		log.Println("Recent rain detected, skipping notification.")
+	precipitation := hour.Precipitation()
+	severity := max(rainSeverity(chance, hour.PrecipMM, r.thresholds), ruleSeverity(rain.Severity))
+	h = hourAlert{
+		kind:     kindRain,
+		report:   string(precipitation),
+		title:    rain.Title,
+		message:  rain.Message,
+		tags:     precipitationTags[precipitation],
+		severity: precipitationSeverity(precipitation, severity),
+		state:    chance,
+		cooldown: rain.Cooldown,
+		repeat:   ruleRepeat(rain, hour, thresholds),
+	}
+	if precipitation == weather.PrecipitationRain && windy(hour, r.thresholds) {
+		h.report, h.tags = message.ReportRainWind, rainWindTags
+		h.severity = max(h.severity, SeverityModerate)
+	}
+	if rain.Hysteresis() {
+		if h, err = withHysteresis(h, rain, hour, thresholds, match); err != nil {
+			return hourAlert{}, false, err
+		}
+	}
+	return h, match, nil
+}
+
+func (a *Alerter) alertNextHour(ctx context.Context, loc location.Location, r recipient, weatherData *weather.WeatherResponse, hour *weather.Hour, quiet location.QuietHours, isQuiet bool) error {
+	h, match, err := a.rainAlert(loc, r, *hour)
+	if err != nil {
+		return err
+	}
+	if h.hysteresis {
+		if match, err = a.enters(ctx, loc, r, h); err != nil {
+			return err
+		}
+	}
+	if !match {
 		return nil
 	}
 
-	msg := a.Ntfy.GenerateRainMessage(weatherData.Location.Name, hour.Time, hour.PrecipMM, hour.ChanceOfRain)
-	if err := a.Ntfy.Send("Rain Alert", msg, "umbrella,robot"); err != nil {
+	if isQuiet {
+		if !quiet.Hold {
+			log.Printf("%s/%s: quiet hours, not notifying.\n", loc.Name, r.name)
+			return nil
+		}
+		log.Printf("%s/%s: quiet hours, holding notification.\n", loc.Name, r.name)
+		a.explain(loc, &r, "hold", true, "held until quiet hours end")
+		held := database.HeldNotification{HourTime: hour.Time, ChanceOfRain: h.state, PrecipMM: hour.PrecipMM}
+		if err := a.Store.HoldNotification(ctx, loc.Name, r.subscriptionID, held); err != nil {
+			return fmt.Errorf("holding notification: %w", err)
+		}
+		return a.activate(ctx, loc, r, h)
+	}
+
+	if !h.hysteresis {
+		notify, reason, err := a.Store.ShouldNotify(ctx, loc.Name, r.subscriptionID, kindRain, h.cooldown, h.repeats)
+		if err != nil {
+			return fmt.Errorf("checking notification history: %w", err)
+		}
+		a.explain(loc, &r, "rain suppression", notify, "%s", reason)
+
+		if !notify {
+			log.Printf("%s/%s: recent rain detected, skipping notification.\n", loc.Name, r.name)
+			return nil
+		}
+	}
+
+	templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if err != nil {
+		return err
+	}
+
+	// Rain uses the rain rule's message or the configurable templates,
+	// everything else a report of its own.
+	title := cmp.Or(h.title, templates.Locale.Title)
+	audience := message.Audience{Location: loc.Name, SubscriptionID: r.subscriptionID}
+	var body, key string
+	switch {
+	case h.report == string(weather.PrecipitationRain) && h.message != "":
+		body, err = templates.Locale.Execute(h.kind, h.message, message.HourData{Name: loc.Name, Hour: *hour})
+	case h.report == string(weather.PrecipitationRain):
+		body, key, err = templates.Render(ctx, audience, message.Data{
+			Name:          loc.Name,
+			Location:      weatherData.Location.Name,
+			Hour:          *hour,
+			Severity:      h.severity.String(),
+			Precipitation: h.report,
+			Windows:       weather.RainWindows(upcomingHours(weatherData.Forecast.ForecastDay[0].Hour, hour.Time), r.thresholds["drizzleThreshold"]),
+			Forecast:      weatherData,
+		})
+	default:
+		title, body, err = templates.Locale.Report(h.report, message.HourData{Name: loc.Name, Hour: *hour})
+	}
+	if err != nil {
+		return fmt.Errorf("rendering message: %w", err)
+	}
+
+	if err := a.send(ctx, loc, r, a.nextHourNotification(title, h.tags, h.severity, body)); err != nil {
 		return fmt.Errorf("sending notification: %w", err)
 	}
+	a.fire(h, hour.Time)
 
-	if err := a.DB.RecordNotification(hour.ChanceOfRain); err != nil {
+	if err := a.Store.RecordNotification(ctx, loc.Name, r.subscriptionID, kindRain, h.state); err != nil {
 		return fmt.Errorf("recording notification: %w", err)
 	}
+	if key != "" {
+		if err := templates.Sent(ctx, audience, key); err != nil {
+			return err
+		}
+	}
 
+	return a.activate(ctx, loc, r, h)
+}
+
+// releaseHeld sends a summary of the alerts held during quiet hours, if any.
+func (a *Alerter) releaseHeld(ctx context.Context, loc location.Location, r recipient) error {
+	held, err := a.Store.GetHeldNotifications(ctx, loc.Name, r.subscriptionID)
+	if err != nil {
+		return fmt.Errorf("getting held notifications: %w", err)
+	}
+	if len(held) == 0 {
+		return nil
+	}
+
+	templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if err != nil {
+		return err
+	}
+
+	data := message.HeldData{Name: loc.Name}
+	for _, n := range held {
+		data.Hours = append(data.Hours, weather.Hour{Time: n.HourTime, ChanceOfRain: n.ChanceOfRain, PrecipMM: n.PrecipMM})
+	}
+	title, body, err := templates.Locale.Report(message.ReportHeld, data)
+	if err != nil {
+		return fmt.Errorf("rendering held summary: %w", err)
+	}
+
+	msg := ntfy.Message{Title: title, Body: body, Tags: []string{"umbrella", "sleeping"}, Icon: a.IconURL}
+	if err := a.send(ctx, loc, r, msg); err != nil {
+		return fmt.Errorf("sending held summary: %w", err)
+	}
+	if a.fired != nil {
+		a.fired(Fired{Kind: message.ReportHeld, Hour: held[len(held)-1].HourTime, State: len(held), Severity: SeverityLow.String()})
+	}
+
+	if err := a.Store.ClearHeldNotifications(ctx, loc.Name, r.subscriptionID); err != nil {
+		return fmt.Errorf("clearing held notifications: %w", err)
+	}
+
+	return nil
+}
+
+// send publishes msg to each of the recipient's topics, or to the default
+// topic when there are none. Dry runs only record it.
+func (a *Alerter) send(ctx context.Context, loc location.Location, r recipient, msg ntfy.Message) error {
+	if a.Explanation != nil {
+		a.explainStep(loc, &r, Step{Check: "send", Passed: true, Detail: fmt.Sprintf("would send to %v", r.topics), Message: &msg})
+		return nil
+	}
+
+	if len(r.topics) == 0 {
+		return a.Notifier.Send(ctx, msg)
+	}
+
+	var errs []error
+	for _, topic := range r.topics {
+		msg.Topic = topic
+		if err := a.Notifier.Send(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Alerter) nextHourNotification(title string, tags []string, severity Severity, body string) ntfy.Message {
+	msg := ntfy.Message{
+		Title:    title,
+		Body:     body,
+		Tags:     tags,
+		Priority: severity.Priority(),
+		Icon:     a.IconURL,
+	}
+	if a.RadarURL != "" {
+		msg.Click = a.RadarURL
+		msg.Actions = []ntfy.Action{{Action: "view", Label: "View radar", URL: a.RadarURL}}
+	}
+	return msg
+}
+
+// upcomingHours returns the hours starting at the given forecast time.
+func upcomingHours(hours []weather.Hour, from string) []weather.Hour {
+	for i, h := range hours {
+		if h.Time >= from {
+			return hours[i:]
+		}
+	}
 	return nil
 }
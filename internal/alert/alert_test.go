@@ -2,16 +2,25 @@ package alert
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/nowcast"
 	"github.com/imedgar/rain-alert/internal/platform/database"
 	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/rule"
 	"github.com/imedgar/rain-alert/internal/weather"
 )
 
@@ -23,6 +32,76 @@ func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
 	return m.DoFunc(req)
 }
 
+// forecastBody returns a one day forecast where the next hour has the given
+// chance of rain.
+func forecastBody(chanceOfRain int) []byte {
+	nextHour := (time.Now().In(time.UTC).Hour() + 1) % 24
+	return forecastBodyWith(map[int]int{nextHour: chanceOfRain})
+}
+
+// forecastBodyWith returns today's UTC forecast with the chance of rain of
+// the given hours set.
+func forecastBodyWith(chances map[int]int) []byte {
+	today := time.Now().UTC().Format(time.DateOnly)
+	weatherResponse := &weather.WeatherResponse{
+		Location: struct {
+			Name           string  `json:"name"`
+			Lat            float64 `json:"lat"`
+			Lon            float64 `json:"lon"`
+			TzID           string  `json:"tz_id"`
+			Localtime      string  `json:"localtime"`
+			LocaltimeEpoch int64   `json:"localtime_epoch"`
+		}{Name: "Test Location", TzID: "UTC"},
+		Forecast: struct {
+			ForecastDay []struct {
+				Date string         `json:"date"`
+				Hour []weather.Hour `json:"hour"`
+			} `json:"forecastday"`
+		}{
+			ForecastDay: []struct {
+				Date string         `json:"date"`
+				Hour []weather.Hour `json:"hour"`
+			}{
+				{
+					Date: today,
+					Hour: make([]weather.Hour, 24),
+				},
+			},
+		},
+	}
+	for i := range weatherResponse.Forecast.ForecastDay[0].Hour {
+		weatherResponse.Forecast.ForecastDay[0].Hour[i] = weather.Hour{
+			Time:         fmt.Sprintf("%s %02d:00", today, i),
+			ChanceOfRain: chances[i],
+		}
+	}
+
+	weatherBody, _ := json.Marshal(weatherResponse)
+	return weatherBody
+}
+
+func newTestAlerter(t *testing.T, httpClient *MockClient, db *sql.DB) *Alerter {
+	t.Helper()
+
+	weatherAPI := weather.NewAPI(httpClient, "http://weather.com", "test-key")
+	ntfyClient := ntfy.New(httpClient, "http://ntfy.sh", "test-topic")
+	messages := &message.Catalog{DefaultSet: message.SetFun, DefaultLocale: "en"}
+	return NewAlerter(weatherAPI, database.New(db), ntfyClient, messages)
+}
+
+var (
+	subscriptionColumns = []string{"id", "user_id", "name", "locale", "location", "topic", "message_set",
+		"drizzle_threshold", "rain_before_threshold", "quiet_hours", "commutes", "disable_next_hour", "digest_at", "outlook"}
+	heldColumns = []string{"hour_time", "chance_of_rain", "precip_mm", "created_at"}
+)
+
+// quietNow returns quiet hours JSON covering the current time.
+func quietNow(hold bool) string {
+	now := time.Now().UTC()
+	return fmt.Sprintf(`[{"start": %q, "end": %q, "hold": %t}]`,
+		now.Add(-time.Hour).Format("15:04"), now.Add(time.Hour).Format("15:04"), hold)
+}
+
 func TestCheckAndAlert(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -30,36 +109,8 @@ func TestCheckAndAlert(t *testing.T) {
 	}
 	defer db.Close()
 
-	dbMock := database.New(db)
-
 	t.Run("Successful alert", func(t *testing.T) {
-		weatherResponse := &weather.WeatherResponse{
-			Location: struct {
-				Name      string `json:"name"`
-				TzID      string `json:"tz_id"`
-				Localtime string `json:"localtime"`
-			}{Name: "Test Location"},
-			Forecast: struct {
-				ForecastDay []struct {
-					Date string `json:"date"`
-					Hour []weather.Hour `json:"hour"`
-				} `json:"forecastday"`
-			}{
-				ForecastDay: []struct {
-					Date string `json:"date"`
-					Hour []weather.Hour `json:"hour"`
-				}{
-					{
-						Date: "2025-07-10",
-						Hour: make([]weather.Hour, 24),
-					},
-				},
-			},
-		}
-		nextHour := (time.Now().In(time.UTC).Hour() + 1) % 24
-		weatherResponse.Forecast.ForecastDay[0].Hour[nextHour] = weather.Hour{ChanceOfRain: 80}
-
-		weatherBody, _ := json.Marshal(weatherResponse)
+		weatherBody := forecastBody(80)
 		mockHTTPClient := &MockClient{
 			DoFunc: func(req *http.Request) (*http.Response, error) {
 				return &http.Response{
@@ -68,21 +119,49 @@ func TestCheckAndAlert(t *testing.T) {
 				}, nil
 			},
 		}
-
-		weatherAPI := weather.NewAPI(mockHTTPClient, "http://weather.com", "test-key")
-		ntfyClient := ntfy.New(mockHTTPClient, "http://ntfy.sh", "test-topic")
-		alerter := NewAlerter(weatherAPI, dbMock, ntfyClient)
+		alerter := newTestAlerter(t, mockHTTPClient, db)
 
 		rows := sqlmock.NewRows([]string{"config", "value"}).
 			AddRow("drizzleThreshold", "50").
 			AddRow("rainBeforeThreshold", "70")
 		mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
-		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("FROM subscriptions").WithArgs("Test Location").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+		mock.ExpectQuery("FROM held_notifications").WithArgs("Test Location", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("Test Location", 0, "rain").WillReturnError(sql.ErrNoRows)
 		mock.ExpectExec("INSERT INTO weather_notifications").
-			WithArgs(80, sqlmock.AnyArg()).
+			WithArgs("Test Location", 0, "rain", 80, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "Test Location", Query: "Test Location", Timezone: "UTC"})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+	t.Run("Records the snapshot", func(t *testing.T) {
+		weatherBody := forecastBody(0)
+		mockHTTPClient := &MockClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+				}, nil
+			},
+		}
+		alerter := newTestAlerter(t, mockHTTPClient, db)
+		alerter.RecordSnapshots = true
+
+		mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).AddRow("drizzleThreshold", "50"))
+		mock.ExpectQuery("FROM subscriptions").WithArgs("Test Location").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+		mock.ExpectExec("INSERT INTO forecast_snapshots").
+			WithArgs("Test Location", sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("FROM held_notifications").WithArgs("Test Location", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
 
-		err := alerter.CheckAndAlert("Test Location", "UTC")
+		err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "Test Location", Query: "Test Location", Timezone: "UTC"})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -92,3 +171,1395 @@ func TestCheckAndAlert(t *testing.T) {
 		}
 	})
 }
+
+func TestRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	weatherBody := forecastBody(60)
+	var mu sync.Mutex
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				mu.Lock()
+				published = append(published, req.URL.Path)
+				mu.Unlock()
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	for range 2 {
+		rows := sqlmock.NewRows([]string{"config", "value"}).
+			AddRow("drizzleThreshold", "50").
+			AddRow("rainBeforeThreshold", "70")
+		mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	}
+	mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM subscriptions").WithArgs("school").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("office", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("school", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("office", 0, "rain").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("office", 0, "rain", 60, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.Run(context.Background(), []location.Location{
+		{Name: "office", Query: "Madrid", Timezone: "UTC", Topics: []string{"office-rain", "team-rain"}},
+		{Name: "school", Query: "Berlin", Timezone: "UTC", Thresholds: map[string]int{"drizzleThreshold": 65}},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(published) != 2 || published[0] == published[1] {
+		t.Errorf("expected one notification per office topic, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var requests int
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return nil, req.Context().Err()
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	mock.ExpectQuery("SELECT config, value FROM weather_config").
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"config", "value"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := alerter.Run(ctx, []location.Location{{Name: "office", Query: "Madrid", Timezone: "UTC"}}); err == nil {
+		t.Error("expected an error, but got nil")
+	}
+	if requests != 0 {
+		t.Errorf("expected no request once cancelled, got %d", requests)
+	}
+}
+
+func TestCheckAndAlertSubscribers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(60)
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.URL.Path+" "+req.Header.Get("Title"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	subs := sqlmock.NewRows(subscriptionColumns).
+		AddRow(1, 10, "ana", "es", "office", "ana-rain", "serious", nil, nil, nil, nil, nil, nil, nil).
+		AddRow(2, 11, "jonas", "de", "office", "jonas-rain", nil, 70, nil, nil, nil, nil, nil, nil).
+		AddRow(3, 12, "night-owl", nil, "office", "owl-rain", nil, nil, nil, quietNow(false), nil, nil, nil, nil).
+		AddRow(4, 13, "sleeper", nil, "office", "sleeper-rain", nil, nil, nil, quietNow(true), nil, nil, nil, nil)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(subs)
+	mock.ExpectQuery("FROM held_notifications").WithArgs("office", 1).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("office", 1, "rain").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("office", 1, "rain", 60, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("office", 2).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectExec("INSERT INTO held_notifications").
+		WithArgs("office", 4, sqlmock.AnyArg(), 60, 0.0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "office", Query: "Madrid", Timezone: "UTC", SubscribersOnly: true})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(published) != 1 || published[0] != "/ana-rain Alerta de lluvia" {
+		t.Errorf("expected only ana to be notified in Spanish, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertSkipsInvalidSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(60)
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.URL.Path)
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	subs := sqlmock.NewRows(subscriptionColumns).
+		AddRow(1, 10, "broken", nil, "office", "broken-rain", nil, nil, nil, "nope", nil, nil, nil, nil).
+		AddRow(3, 12, "joao", "pt", "office", "joao-rain", nil, nil, nil, nil, nil, nil, nil, nil).
+		AddRow(4, 13, "lea", nil, "office", "lea-rain", "nope", nil, nil, nil, nil, nil, nil, nil).
+		AddRow(2, 11, "ana", nil, "office", "ana-rain", nil, nil, nil, nil, nil, nil, nil, nil)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(subs)
+	mock.ExpectQuery("FROM held_notifications").WithArgs("office", 2).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("office", 2, "rain").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("office", 2, "rain", 60, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "office", Query: "Madrid", Timezone: "UTC", SubscribersOnly: true})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(published, []string{"/ana-rain"}) {
+		t.Errorf("expected only ana to be notified, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertMessageHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(80)
+	ntfyStatus := http.StatusOK
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				return &http.Response{StatusCode: ntfyStatus, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	alerter.Messages.NewPicker = func() (message.Picker, error) {
+		return message.NewPicker(message.StrategyRoundRobin, 0, alerter.DB)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		status int
+	}{
+		{"Failed send is not recorded", http.StatusBadGateway},
+		{"Sent message is recorded", http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ntfyStatus = tt.status
+			mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).AddRow("drizzleThreshold", "50"))
+			mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+			mock.ExpectQuery("FROM held_notifications").WithArgs("office", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+			mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("office", 0, "rain").WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery("SELECT template FROM message_history").WithArgs("office", 0, 1).WillReturnRows(sqlmock.NewRows([]string{"template"}))
+			if tt.status == http.StatusOK {
+				mock.ExpectExec("INSERT INTO weather_notifications").
+					WithArgs("office", 0, "rain", 80, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO message_history").
+					WithArgs("office", 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err := alerter.CheckAndAlert(context.Background(), location.Location{Name: "office", Query: "Madrid", Timezone: "UTC"})
+			if (err != nil) != (tt.status != http.StatusOK) {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestCheckAndAlertReleasesHeld(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(10)
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.Header.Get("Title"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	held := sqlmock.NewRows(heldColumns).
+		AddRow("2025-07-10 03:00", 80, 1.2, time.Now().Unix()).
+		AddRow("2025-07-10 04:00", 65, 0.4, time.Now().Unix())
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(held)
+	mock.ExpectExec("DELETE FROM held_notifications").WithArgs("home", 0).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(published) != 1 || published[0] != "Rain alerts during quiet hours" {
+		t.Errorf("expected the held summary only, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertCommute(t *testing.T) {
+	now := time.Now().UTC()
+	start := now.Add(50 * time.Minute)
+	end := start.Add(30 * time.Minute)
+	if end.Day() != now.Day() {
+		t.Skip("commute would cross midnight")
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBodyWith(map[int]int{start.Hour(): 80, end.Hour(): 80})
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.Header.Get("Title"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	commutes := fmt.Sprintf(`[{"name": "bike", "start": %q, "end": %q, "lead": "15m"}, {"name": "later", "start": %q, "end": %q}]`,
+		start.Format("15:04"), end.Format("15:04"),
+		now.Add(-3*time.Hour).Format("15:04"), now.Add(-2*time.Hour).Format("15:04"))
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	subs := sqlmock.NewRows(subscriptionColumns).
+		AddRow(1, 10, "cyclist", nil, "office", "cyclist-rain", nil, nil, nil, nil, commutes, true, nil, nil)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(subs)
+	mock.ExpectQuery("FROM held_notifications").WithArgs("office", 1).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT id FROM daily_notifications").
+		WithArgs("office", 1, "commute:bike", start.Format(time.DateOnly)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO daily_notifications").
+		WithArgs("office", 1, "commute:bike", start.Format(time.DateOnly), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "office", Query: "Madrid", Timezone: "UTC", SubscribersOnly: true})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(published) != 1 || published[0] != "Rain on your commute" {
+		t.Errorf("expected one commute alert, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertDigest(t *testing.T) {
+	if time.Now().UTC().Format("15:04") == "23:59" {
+		t.Skip("late digest would be due too")
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(10)
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.Header.Get("Title"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	today := time.Now().UTC().Format(time.DateOnly)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	subs := sqlmock.NewRows(subscriptionColumns).
+		AddRow(1, 10, "early", nil, "home", "early-rain", nil, nil, nil, nil, nil, true, "00:00", nil).
+		AddRow(2, 11, "late", nil, "home", "late-rain", nil, nil, nil, nil, nil, true, "23:59", nil)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(subs)
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 1).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT id FROM daily_notifications").
+		WithArgs("home", 1, "digest", today).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO daily_notifications").
+		WithArgs("home", 1, "digest", today, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 2).WillReturnRows(sqlmock.NewRows(heldColumns))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC", SubscribersOnly: true})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(published) != 1 || published[0] != "Daily rain digest" {
+		t.Errorf("expected one digest, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertOutlook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var weatherResponse weather.WeatherResponse
+	json.Unmarshal(forecastBody(10), &weatherResponse)
+	start := time.Now().UTC()
+	for i := 1; i <= 3; i++ {
+		day := weatherResponse.Forecast.ForecastDay[0]
+		day.Date = start.AddDate(0, 0, i).Format(time.DateOnly)
+		day.Hour = make([]weather.Hour, 24)
+		for h := range day.Hour {
+			day.Hour[h] = weather.Hour{Time: fmt.Sprintf("%s %02d:00", day.Date, h)}
+		}
+		if i == 2 {
+			day.Hour[8].ChanceOfRain = 80
+			day.Hour[8].PrecipMM = 2.5
+		}
+		weatherResponse.Forecast.ForecastDay = append(weatherResponse.Forecast.ForecastDay, day)
+	}
+	weatherBody, _ := json.Marshal(weatherResponse)
+
+	var requestedDays, published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				body, _ := io.ReadAll(req.Body)
+				published = append(published, req.Header.Get("Title")+": "+string(body))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			requestedDays = append(requestedDays, req.URL.Query().Get("days"))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	today := start.Format(time.DateOnly)
+
+	for _, tt := range []struct {
+		name     string
+		timezone string
+		expected []string
+	}{
+		{"Configured timezone", "UTC", []string{"4"}},
+		// The timezone is only known from the first forecast.
+		{"Timezone from the forecast", "", []string{"1", "4"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requestedDays, published = nil, nil
+
+			rows := sqlmock.NewRows([]string{"config", "value"}).
+				AddRow("drizzleThreshold", "50").
+				AddRow("rainBeforeThreshold", "70")
+			mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+			subs := sqlmock.NewRows(subscriptionColumns).
+				AddRow(1, 10, "planner", nil, "home", "planner-rain", nil, nil, nil, nil, nil, true, nil, `{"days": 3, "on": "daily", "at": "00:00"}`)
+			mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(subs)
+			mock.ExpectQuery("FROM held_notifications").WithArgs("home", 1).WillReturnRows(sqlmock.NewRows(heldColumns))
+			mock.ExpectQuery("SELECT id FROM daily_notifications").
+				WithArgs("home", 1, "outlook", today).
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectExec("INSERT INTO daily_notifications").
+				WithArgs("home", 1, "outlook", today, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: tt.timezone, SubscribersOnly: true})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !slices.Equal(requestedDays, tt.expected) {
+				t.Errorf("expected %v days to be requested, got %v", tt.expected, requestedDays)
+			}
+			if len(published) != 1 || !strings.HasPrefix(published[0], "Rain outlook: ") {
+				t.Fatalf("expected one outlook, got %v", published)
+			}
+			if lines := strings.Split(published[0], "\n"); len(lines) != 4 || !strings.Contains(lines[2], "2.50 mm, up to 80%") {
+				t.Errorf("unexpected outlook: %q", published[0])
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestCheckAndAlertWarnings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	expires := time.Now().Add(time.Hour).Format(time.RFC3339)
+	storm := weather.Alert{Headline: "Red storm warning", Severity: "Extreme", Event: "Storm", Expires: expires}
+	flood := weather.Alert{Headline: "Flood warning", Severity: "Moderate", Event: "Flood", Expires: expires}
+	expired := weather.Alert{Headline: "Heat warning", Event: "Heat", Expires: time.Now().Add(-time.Hour).Format(time.RFC3339)}
+
+	var weatherResponse weather.WeatherResponse
+	json.Unmarshal(forecastBody(10), &weatherResponse)
+	weatherResponse.Alerts.Alert = []weather.Alert{storm, flood, expired}
+	weatherBody, _ := json.Marshal(weatherResponse)
+
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				body, _ := io.ReadAll(req.Body)
+				published = append(published, req.Header.Get("Priority")+" "+string(body))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT version FROM weather_alerts").
+		WithArgs("home", 0, storm.ID()).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	mock.ExpectExec("INSERT INTO weather_alerts").
+		WithArgs("home", 0, storm.ID(), storm.Version(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT version FROM weather_alerts").
+		WithArgs("home", 0, flood.ID()).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(flood.Version()))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(published) != 1 || published[0] != "5 Red storm warning in home" {
+		t.Errorf("expected the new storm warning only, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertSameEventWarnings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	expires := time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+	today := weather.Alert{Headline: "Thunderstorms today", Severity: "Moderate", Event: "Thunderstorms", Areas: "Madrid", Effective: time.Now().Format(time.RFC3339), Expires: expires}
+	tomorrow := today
+	tomorrow.Headline = "Thunderstorms tomorrow"
+	tomorrow.Effective = time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+
+	var weatherResponse weather.WeatherResponse
+	json.Unmarshal(forecastBody(10), &weatherResponse)
+	weatherResponse.Alerts.Alert = []weather.Alert{today, tomorrow}
+	weatherBody, _ := json.Marshal(weatherResponse)
+
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				body, _ := io.ReadAll(req.Body)
+				published = append(published, string(body))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	loc := location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"}
+
+	expectRun := func(forwarded ...string) {
+		rows := sqlmock.NewRows([]string{"config", "value"}).
+			AddRow("drizzleThreshold", "50").
+			AddRow("rainBeforeThreshold", "70")
+		mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+		mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+		mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+		for _, w := range []weather.Alert{today, tomorrow} {
+			versions := sqlmock.NewRows([]string{"version"})
+			for _, v := range forwarded {
+				versions.AddRow(v)
+			}
+			mock.ExpectQuery("SELECT version FROM weather_alerts").WithArgs("home", 0, w.ID()).WillReturnRows(versions)
+			if !slices.Contains(forwarded, w.Version()) {
+				mock.ExpectExec("INSERT INTO weather_alerts").
+					WithArgs("home", 0, w.ID(), w.Version(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				forwarded = append(forwarded, w.Version())
+			}
+		}
+	}
+
+	expectRun()
+	if err := alerter.CheckAndAlert(context.Background(), loc); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expectRun(today.Version(), tomorrow.Version())
+	if err := alerter.CheckAndAlert(context.Background(), loc); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(published) != 2 {
+		t.Errorf("expected both warnings to be sent once, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertWarningFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storm := weather.Alert{Headline: "Red storm warning", Severity: "Extreme", Event: "Storm", Expires: time.Now().Add(time.Hour).Format(time.RFC3339)}
+
+	var weatherResponse weather.WeatherResponse
+	json.Unmarshal(forecastBody(80), &weatherResponse)
+	weatherResponse.Alerts.Alert = []weather.Alert{storm}
+	weatherBody, _ := json.Marshal(weatherResponse)
+
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				if req.Header.Get("Tags") == "warning" {
+					return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+				}
+				published = append(published, req.Header.Get("Title"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT version FROM weather_alerts").
+		WithArgs("home", 0, storm.ID()).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("home", 0, "rain").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("home", 0, "rain", 80, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"})
+	if err == nil || !strings.Contains(err.Error(), "sending warning") {
+		t.Errorf("expected the warning to fail, got %v", err)
+	}
+
+	if !slices.Equal(published, []string{"Rain Alert"}) {
+		t.Errorf("expected the rain alert to be sent anyway, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertSnow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var weatherResponse weather.WeatherResponse
+	json.Unmarshal(forecastBody(0), &weatherResponse)
+	nextHour := &weatherResponse.Forecast.ForecastDay[0].Hour[(time.Now().UTC().Hour()+1)%24]
+	nextHour.ChanceOfSnow = 75
+	nextHour.SnowCM = 3
+	nextHour.Condition.Code = 1219
+	weatherBody, _ := json.Marshal(weatherResponse)
+
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.Header.Get("Title")+" "+req.Header.Get("Tags"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("home", 0, "rain").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("home", 0, "rain", 75, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Berlin", Timezone: "UTC"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(published) != 1 || published[0] != "Snow alert snowflake,robot" {
+		t.Errorf("expected a snow alert, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertWind(t *testing.T) {
+	tests := []struct {
+		name         string
+		chanceOfRain int
+		kind         string
+		expected     string
+	}{
+		{"Dry", 10, "wind", "Wind alert dash,robot"},
+		{"Rain", 80, "rain", "Rain and strong gusts umbrella,dash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			var weatherResponse weather.WeatherResponse
+			json.Unmarshal(forecastBody(tt.chanceOfRain), &weatherResponse)
+			nextHour := &weatherResponse.Forecast.ForecastDay[0].Hour[(time.Now().UTC().Hour()+1)%24]
+			nextHour.WindKPH = 35
+			nextHour.GustKPH = 62
+			nextHour.WindDir = "SW"
+			weatherBody, _ := json.Marshal(weatherResponse)
+
+			var published []string
+			mockHTTPClient := &MockClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+						published = append(published, req.Header.Get("Title")+" "+req.Header.Get("Tags"))
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+					}, nil
+				},
+			}
+			alerter := newTestAlerter(t, mockHTTPClient, db)
+
+			rows := sqlmock.NewRows([]string{"config", "value"}).
+				AddRow("drizzleThreshold", "50").
+				AddRow("rainBeforeThreshold", "70")
+			mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+			mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+			mock.ExpectQuery("FROM held_notifications").WithArgs("office", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+			mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("office", 0, tt.kind).WillReturnError(sql.ErrNoRows)
+			mock.ExpectExec("INSERT INTO weather_notifications").
+				WithArgs("office", 0, tt.kind, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			loc := location.Location{Name: "office", Query: "Madrid", Timezone: "UTC", Thresholds: map[string]int{"gustThreshold": 50}}
+			if err := alerter.CheckAndAlert(context.Background(), loc); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if len(published) != 1 || published[0] != tt.expected {
+				t.Errorf("expected %q, got %v", tt.expected, published)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestCheckAndAlertAirQuality(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var weatherResponse weather.WeatherResponse
+	json.Unmarshal(forecastBody(10), &weatherResponse)
+	nextHour := &weatherResponse.Forecast.ForecastDay[0].Hour[(time.Now().UTC().Hour()+1)%24]
+	nextHour.AirQuality = &weather.AirQuality{PM25: 40, USEPAIndex: weather.AQIUnhealthyForSensitive}
+	weatherBody, _ := json.Marshal(weatherResponse)
+
+	var aqi string
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.Header.Get("Title"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			aqi = req.URL.Query().Get("aqi")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70").
+		AddRow("pm25Threshold", "35")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("school").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("school", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("school", 0, "air-quality").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("school", 0, "air-quality", weather.AQIUnhealthyForSensitive, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "school", Query: "Getafe", Timezone: "UTC", AirQuality: true})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if aqi != "yes" {
+		t.Errorf("expected air quality to be requested, got %q", aqi)
+	}
+	if len(published) != 1 || published[0] != "Air quality alert" {
+		t.Errorf("expected an air quality alert, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertHeat(t *testing.T) {
+	tests := []struct {
+		name     string
+		isDay    int
+		expected []string
+	}{
+		{"Day", 1, []string{"UV alert", "Heat alert"}},
+		{"Night", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			var weatherResponse weather.WeatherResponse
+			json.Unmarshal(forecastBody(10), &weatherResponse)
+			nextHour := &weatherResponse.Forecast.ForecastDay[0].Hour[(time.Now().UTC().Hour()+1)%24]
+			nextHour.IsDay = tt.isDay
+			nextHour.UV = 9
+			nextHour.FeelsLikeC = 36.5
+			nextHour.HeatIndexC = 35
+			weatherBody, _ := json.Marshal(weatherResponse)
+
+			var published []string
+			mockHTTPClient := &MockClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+						published = append(published, req.Header.Get("Title"))
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+					}, nil
+				},
+			}
+			alerter := newTestAlerter(t, mockHTTPClient, db)
+
+			rows := sqlmock.NewRows([]string{"config", "value"}).
+				AddRow("drizzleThreshold", "50").
+				AddRow("rainBeforeThreshold", "70")
+			mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+			mock.ExpectQuery("FROM subscriptions").WithArgs("school").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+			mock.ExpectQuery("FROM held_notifications").WithArgs("school", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+			if tt.isDay == 1 {
+				mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("school", 0, "uv").WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec("INSERT INTO weather_notifications").
+					WithArgs("school", 0, "uv", 9, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				// A recent but milder heat alert does not suppress this one.
+				mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("school", 0, "heat").
+					WillReturnRows(sqlmock.NewRows([]string{"state", "created_at"}).AddRow(33, time.Now().Unix()))
+				mock.ExpectExec("INSERT INTO weather_notifications").
+					WithArgs("school", 0, "heat", 36, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			loc := location.Location{Name: "school", Query: "Sevilla", Timezone: "UTC", Thresholds: map[string]int{"uvThreshold": 8, "heatThreshold": 32}}
+			if err := alerter.CheckAndAlert(context.Background(), loc); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(published, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, published)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestCheckAndAlertRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var weatherResponse weather.WeatherResponse
+	json.Unmarshal(forecastBody(80), &weatherResponse)
+	nextHour := &weatherResponse.Forecast.ForecastDay[0].Hour[(time.Now().UTC().Hour()+1)%24]
+	nextHour.GustKPH = 48
+	weatherBody, _ := json.Marshal(weatherResponse)
+
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				body, _ := io.ReadAll(req.Body)
+				published = append(published, req.Header.Get("Title")+": "+string(body)+" "+req.Header.Get("Priority"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	alerter.Rules, err = rule.Compile([]rule.Definition{
+		{Name: rule.Rain, When: "chance >= $drizzleThreshold + 20"},
+		{Name: "windy-ride", When: "gust_kph >= 45 and chance > 50", Severity: "moderate", Cooldown: "3h", Title: "Windy ride", Message: "Gusts up to {{kph .Hour.GustKPH}} km/h in {{.Name}}."},
+	}, map[string]int{"drizzleThreshold": 70, "rainBeforeThreshold": 80})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "70").
+		AddRow("rainBeforeThreshold", "80")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("office", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("office", 0, "rule:windy-ride").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("office", 0, "rule:windy-ride", 80, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "office", Query: "Madrid", Timezone: "UTC"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := "Windy ride: Gusts up to 48 km/h in office. 4"
+	if len(published) != 1 || published[0] != expected {
+		t.Errorf("expected only %q, got %v", expected, published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertRainRule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(80)
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				body, _ := io.ReadAll(req.Body)
+				published = append(published, req.Header.Get("Title")+": "+string(body)+" "+req.Header.Get("Priority"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	alerter.Rules, err = rule.Compile([]rule.Definition{
+		{Name: rule.Rain, When: "chance >= 60", Repeat: "last_chance < 70", Severity: "high", Cooldown: "2h", Message: "Rain in {{.Name}}."},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		last      *sqlmock.Rows
+		published []string
+	}{
+		{
+			name:      "Cooldown and repeat condition",
+			last:      sqlmock.NewRows([]string{"state", "created_at"}).AddRow(75, time.Now().Add(-90*time.Minute).Unix()),
+			published: nil,
+		},
+		{
+			name:      "Severity and message",
+			last:      sqlmock.NewRows([]string{"state", "created_at"}).AddRow(65, time.Now().Add(-90*time.Minute).Unix()),
+			published: []string{"Rain Alert: Rain in office. 5"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			published = nil
+			mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(sqlmock.NewRows([]string{"config", "value"}))
+			mock.ExpectQuery("FROM subscriptions").WithArgs("office").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+			mock.ExpectQuery("FROM held_notifications").WithArgs("office", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+			mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("office", 0, "rain").WillReturnRows(tt.last)
+			if tt.published != nil {
+				mock.ExpectExec("INSERT INTO weather_notifications").
+					WithArgs("office", 0, "rain", 80, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err := alerter.CheckAndAlert(context.Background(), location.Location{Name: "office", Query: "Madrid", Timezone: "UTC"})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(published, tt.published) {
+				t.Errorf("expected %v, got %v", tt.published, published)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestCheckAndAlertDryRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(80)
+	var published int
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published++
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	alerter.DB.ReadOnly = true
+	alerter.Explanation = &Explanation{}
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("home", 0, "rain").WillReturnError(sql.ErrNoRows)
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if published != 0 {
+		t.Errorf("expected nothing to be sent, got %d", published)
+	}
+
+	var checks []string
+	var sent *ntfy.Message
+	for _, s := range alerter.Explanation.Steps() {
+		checks = append(checks, fmt.Sprintf("%s=%t", s.Check, s.Passed))
+		if s.Check == "send" {
+			sent = s.Message
+		}
+	}
+	expected := []string{"timezone=true", "forecast=true", "thresholds=true", "quiet hours=true", "rain=true", "rain suppression=true", "send=true"}
+	if !slices.Equal(checks, expected) {
+		t.Errorf("expected steps %v, got %v", expected, checks)
+	}
+	if sent == nil || sent.Title != "Rain Alert" || sent.Body == "" {
+		t.Errorf("expected the message that would have been sent, got %+v", sent)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertHysteresis(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	for _, tt := range []struct {
+		name    string
+		chance  int
+		active  bool
+		publish int
+	}{
+		{"Enters", 60, false, 1},
+		{"Stays active", 48, true, 0},
+		{"Exits", 30, true, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			weatherBody := forecastBody(tt.chance)
+			var published int
+			mockHTTPClient := &MockClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+						published++
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+					}, nil
+				},
+			}
+			alerter := newTestAlerter(t, mockHTTPClient, db)
+
+			rows := sqlmock.NewRows([]string{"config", "value"}).
+				AddRow("drizzleThreshold", "50").
+				AddRow("drizzleExitThreshold", "40").
+				AddRow("rainBeforeThreshold", "70")
+			mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+			mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+			mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+			mock.ExpectQuery("SELECT active FROM alert_states").WithArgs("home", 0, "rain").
+				WillReturnRows(sqlmock.NewRows([]string{"active"}).AddRow(tt.active))
+			switch tt.name {
+			case "Enters":
+				mock.ExpectExec("INSERT INTO weather_notifications").
+					WithArgs("home", 0, "rain", 60, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO alert_states").
+					WithArgs("home", 0, "rain", true, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			case "Exits":
+				mock.ExpectExec("INSERT INTO alert_states").
+					WithArgs("home", 0, "rain", false, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if published != tt.publish {
+				t.Errorf("expected %d notifications, got %d", tt.publish, published)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+type fakeNowcast struct {
+	n   *nowcast.Nowcast
+	err error
+}
+
+func (f fakeNowcast) Nowcast(ctx context.Context, lat, lon float64) (*nowcast.Nowcast, error) {
+	return f.n, f.err
+}
+
+func TestCheckAndAlertNowcast(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(0)
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				body, _ := io.ReadAll(req.Body)
+				published = append(published, req.Header.Get("Delay")+" "+string(body))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+
+	// Dry for half an hour, then raining for half an hour.
+	n := &nowcast.Nowcast{Interval: 15 * time.Minute}
+	now := time.Now()
+	for i, mm := range []float64{0, 0, 0.5, 0.3, 0, 0} {
+		n.Steps = append(n.Steps, nowcast.Step{Time: now.Add(time.Duration(i) * n.Interval), PrecipMM: mm})
+	}
+	alerter.Nowcast = fakeNowcast{n: n}
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT active FROM alert_states").WithArgs("home", 0, "nowcast").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("home", 0, "nowcast", 30, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO alert_states").
+		WithArgs("home", 0, "nowcast", true, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := "15m Rain in ~15 minutes in home, lasting ~30 minutes: 0.80 mm."
+	if len(published) != 1 || published[0] != expected {
+		t.Errorf("expected only %q, got %v", expected, published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAndAlertNowcastFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	weatherBody := forecastBody(80)
+	var published []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.URL.String(), "http://ntfy.sh/") {
+				published = append(published, req.Header.Get("Title"))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(weatherBody)),
+			}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	alerter.Nowcast = fakeNowcast{err: fmt.Errorf("unexpected status code: 503")}
+
+	rows := sqlmock.NewRows([]string{"config", "value"}).
+		AddRow("drizzleThreshold", "50").
+		AddRow("rainBeforeThreshold", "70")
+	mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
+	mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+	mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("home", 0, "rain").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO weather_notifications").
+		WithArgs("home", 0, "rain", 80, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = alerter.CheckAndAlert(context.Background(), location.Location{Name: "home", Query: "Madrid", Timezone: "UTC"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(published, []string{"Rain Alert"}) {
+		t.Errorf("expected the hourly rain alert, got %v", published)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRainSeverity(t *testing.T) {
+	tests := []struct {
+		name       string
+		chance     int
+		precipMM   float64
+		thresholds map[string]int
+		expected   Severity
+	}{
+		{"Below rainBeforeThreshold", 60, 5, map[string]int{"rainBeforeThreshold": 70}, SeverityLow},
+		{"Defaults to rainBeforeThreshold", 70, 1, map[string]int{"rainBeforeThreshold": 70}, SeverityModerate},
+		{"Heavy rain", 70, 5, map[string]int{"rainBeforeThreshold": 70}, SeverityHigh},
+		{"Below rainHighThreshold", 80, 5, map[string]int{"rainBeforeThreshold": 70, "rainHighThreshold": 90}, SeverityLow},
+		{"rainHighThreshold", 90, 1, map[string]int{"rainBeforeThreshold": 70, "rainHighThreshold": 90}, SeverityModerate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if severity := rainSeverity(tt.chance, tt.precipMM, tt.thresholds); severity != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, severity)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// Fired is a notification a backtest would have sent.
+type Fired struct {
+	// Time is the simulated clock, when the snapshot was fetched.
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Report is the alert sent for the kind, such as snow or rain-wind for
+	// rain.
+	Report   string `json:"report,omitempty"`
+	Hour     string `json:"hour"`
+	State    int    `json:"state"`
+	Severity string `json:"severity"`
+}
+
+// Backtest replays forecast snapshots, oldest first, through a location's
+// next-hour alerts with the given thresholds, using each snapshot's time as
+// the clock, and returns the notifications that would have been sent.
+//
+// The alerts run as they do live, with their history, states and held
+// notifications kept in memory: nothing is sent and the database is not
+// used. Subscriptions, commutes, digests, outlooks, warnings and nowcasts
+// are not replayed.
+func (a *Alerter) Backtest(ctx context.Context, loc location.Location, thresholds map[string]int, snapshots []weather.Snapshot) ([]Fired, error) {
+	r := locationRecipient(loc, thresholds)
+	if r.disableNextHour {
+		return nil, nil
+	}
+
+	store := newMemoryStore()
+	var fired []Fired
+	bt := &Alerter{
+		Notifier: discard{},
+		Messages: &message.Catalog{DefaultSet: message.SetFun, DefaultLocale: "en"},
+		Store:    store,
+		Rules:    a.Rules,
+		fired: func(f Fired) {
+			f.Time = store.now
+			fired = append(fired, f)
+		},
+	}
+
+	for _, s := range snapshots {
+		tz, err := a.timezone(loc, s.Forecast)
+		if err != nil {
+			return nil, err
+		}
+		store.now = s.Time.In(tz)
+		hour, err := s.Forecast.NextHour(store.now)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot at %s: %w", store.now.Format(time.RFC3339), err)
+		}
+
+		quiet, isQuiet := location.ActiveQuietHours(r.quietHours, store.now)
+		if !isQuiet {
+			if err := bt.releaseHeld(ctx, loc, r); err != nil {
+				return nil, err
+			}
+		}
+		if err := bt.alertHour(ctx, loc, r, s.Forecast, hour, quiet, isQuiet); err != nil {
+			return nil, err
+		}
+	}
+	return fired, nil
+}
+
+// discard is a Notifier sending nothing.
+type discard struct{}
+
+func (discard) Send(ctx context.Context, msg ntfy.Message) error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package alert
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/schedule"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+func TestBacktest(t *testing.T) {
+	forecast := forecastBodyWith(map[int]int{7: 80, 8: 60, 9: 60})
+	var snapshots []weather.Snapshot
+	for h := 6; h <= 9; h++ {
+		s, err := weather.ParseSnapshot(forecast, time.Date(2025, 7, 10, h, 5, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	loc := location.Location{Name: "home", Timezone: "UTC"}
+	alerter := &Alerter{}
+
+	hours := func(fired []Fired) []string {
+		var hours []string
+		for _, f := range fired {
+			hours = append(hours, f.Kind+"@"+f.Time.Format("15:04"))
+		}
+		return hours
+	}
+
+	for _, tt := range []struct {
+		name       string
+		loc        location.Location
+		thresholds map[string]int
+		expected   []string
+	}{
+		{
+			name:       "Cooldown suppresses a lower chance",
+			loc:        loc,
+			thresholds: map[string]int{"drizzleThreshold": 50, "rainBeforeThreshold": 70},
+			expected:   []string{"rain@06:05", "rain@08:05"},
+		},
+		{
+			name:       "Candidate threshold",
+			loc:        loc,
+			thresholds: map[string]int{"drizzleThreshold": 70, "rainBeforeThreshold": 70},
+			expected:   []string{"rain@06:05"},
+		},
+		{
+			name:       "Unset threshold reads as 0",
+			loc:        loc,
+			thresholds: map[string]int{"rainBeforeThreshold": 70},
+			expected:   []string{"rain@06:05", "rain@08:05", "rain@09:05"},
+		},
+		{
+			name: "Quiet hours hold",
+			loc: location.Location{Name: "home", Timezone: "UTC", QuietHours: []location.QuietHours{
+				{Window: schedule.Window{Start: "07:00", End: "08:00"}, Hold: true},
+			}},
+			thresholds: map[string]int{"drizzleThreshold": 50, "rainBeforeThreshold": 70},
+			expected:   []string{"rain@06:05", "held@08:05", "rain@08:05"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fired, err := alerter.Backtest(context.Background(), tt.loc, tt.thresholds, snapshots)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := hours(fired); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBacktestHysteresis(t *testing.T) {
+	forecast := forecastBodyWith(map[int]int{7: 52, 8: 48, 9: 52, 10: 35, 11: 52})
+	var snapshots []weather.Snapshot
+	for h := 6; h <= 10; h++ {
+		s, err := weather.ParseSnapshot(forecast, time.Date(2025, 7, 10, h, 5, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	loc := location.Location{Name: "home", Timezone: "UTC"}
+	alerter := &Alerter{}
+
+	for _, tt := range []struct {
+		name       string
+		thresholds map[string]int
+		expected   int
+	}{
+		{"Flaps after the cooldown", map[string]int{"drizzleThreshold": 50, "rainBeforeThreshold": 70}, 3},
+		{"Fires on transitions", map[string]int{"drizzleThreshold": 50, "drizzleExitThreshold": 40, "rainBeforeThreshold": 70}, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fired, err := alerter.Backtest(context.Background(), loc, tt.thresholds, snapshots)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(fired) != tt.expected {
+				t.Errorf("expected %d notifications, got %+v", tt.expected, fired)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// alertCommutes notifies once per day for each commute that has rain forecast
+// inside it, at the last run before its lead time. Runs are runInterval apart,
+// so that is the first run with the commute starting within its lead time
+// plus one interval; a lead shorter than the interval would otherwise fall
+// between runs.
+func (a *Alerter) alertCommutes(ctx context.Context, loc location.Location, r recipient, weatherData *weather.WeatherResponse, now time.Time) error {
+	for _, c := range r.commutes {
+		start, end, ok := c.NextStart(now)
+		if !ok || start.Sub(now) > c.LeadTime()+runInterval {
+			continue
+		}
+
+		windows := weather.RainWindows(weatherData.HoursBetween(start, end), r.thresholds["drizzleThreshold"])
+		if len(windows) == 0 {
+			log.Printf("%s/%s: no rain during %s commute.\n", loc.Name, r.name, c.Name)
+			continue
+		}
+
+		day := start.Format(time.DateOnly)
+		notified, err := a.DB.DailyNotified(ctx, loc.Name, r.subscriptionID, "commute:"+c.Name, day)
+		if err != nil {
+			return fmt.Errorf("checking commute history: %w", err)
+		}
+		if notified {
+			log.Printf("%s/%s: %s commute already notified.\n", loc.Name, r.name, c.Name)
+			continue
+		}
+
+		if err := a.sendCommute(ctx, loc, r, c, windows); err != nil {
+			return err
+		}
+
+		if err := a.DB.RecordDailyNotification(ctx, loc.Name, r.subscriptionID, "commute:"+c.Name, day); err != nil {
+			return fmt.Errorf("recording commute notification: %w", err)
+		}
+	}
+	return nil
+}
+
+func (a *Alerter) sendCommute(ctx context.Context, loc location.Location, r recipient, c location.Commute, windows []weather.Window) error {
+	templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if err != nil {
+		return err
+	}
+
+	data := message.CommuteData{Name: loc.Name, Commute: c.Name, Start: c.Start, End: c.End}
+	for _, w := range windows {
+		data.TotalMM += w.TotalMM
+		data.MaxChance = max(data.MaxChance, w.MaxChance)
+	}
+
+	title, body, err := templates.Locale.Report(message.ReportCommute, data)
+	if err != nil {
+		return fmt.Errorf("rendering commute alert: %w", err)
+	}
+
+	msg := ntfy.Message{Title: title, Body: body, Tags: []string{"umbrella", "bike"}, Priority: ntfy.PriorityHigh, Icon: a.IconURL}
+	if err := a.send(ctx, loc, r, msg); err != nil {
+		return fmt.Errorf("sending commute alert: %w", err)
+	}
+	return nil
+}
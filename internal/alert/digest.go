@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/schedule"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+const digestKind = "digest"
+
+// sendDigest sends the day's summary on the first run at or after the
+// recipient's digest time, once a day.
+func (a *Alerter) sendDigest(ctx context.Context, loc location.Location, r recipient, weatherData *weather.WeatherResponse, now time.Time) error {
+	if r.digestAt == "" || len(weatherData.Forecast.ForecastDay) == 0 {
+		return nil
+	}
+
+	at, err := schedule.MinuteOfDay(r.digestAt)
+	if err != nil {
+		return fmt.Errorf("digest time: %w", err)
+	}
+	if now.Hour()*60+now.Minute() < at {
+		return nil
+	}
+
+	day := now.Format(time.DateOnly)
+	notified, err := a.DB.DailyNotified(ctx, loc.Name, r.subscriptionID, digestKind, day)
+	if err != nil {
+		return fmt.Errorf("checking digest history: %w", err)
+	}
+	if notified {
+		return nil
+	}
+
+	templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if err != nil {
+		return err
+	}
+
+	today := weatherData.Forecast.ForecastDay[0]
+	data := message.DigestData{
+		Name:    loc.Name,
+		Date:    today.Date,
+		Summary: weather.Summarize(today.Hour, r.thresholds["drizzleThreshold"]),
+	}
+	title, body, err := templates.Locale.Report(message.ReportDigest, data)
+	if err != nil {
+		return fmt.Errorf("rendering digest: %w", err)
+	}
+
+	msg := ntfy.Message{Title: title, Body: body, Tags: []string{"calendar", "umbrella"}, Priority: ntfy.PriorityLow, Icon: a.IconURL}
+	if err := a.send(ctx, loc, r, msg); err != nil {
+		return fmt.Errorf("sending digest: %w", err)
+	}
+	log.Printf("%s/%s: digest sent.\n", loc.Name, r.name)
+
+	if err := a.DB.RecordDailyNotification(ctx, loc.Name, r.subscriptionID, digestKind, day); err != nil {
+		return fmt.Errorf("recording digest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// Step is one decision taken while checking a location for a recipient.
+type Step struct {
+	Location  string `json:"location"`
+	Recipient string `json:"recipient,omitempty"`
+	Check     string `json:"check"`
+	Passed    bool   `json:"passed"`
+	Detail    string `json:"detail,omitempty"`
+
+	Hour       *weather.Hour  `json:"hour,omitempty"`
+	Thresholds map[string]int `json:"thresholds,omitempty"`
+	Message    *ntfy.Message  `json:"message,omitempty"`
+}
+
+// Explanation records the steps of a dry run. It is safe for concurrent use.
+type Explanation struct {
+	mu    sync.Mutex
+	steps []Step
+}
+
+func (e *Explanation) add(s Step) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.steps = append(e.steps, s)
+}
+
+// Steps returns the steps grouped by location, in the order they were taken.
+func (e *Explanation) Steps() []Step {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	steps := slices.Clone(e.steps)
+	slices.SortStableFunc(steps, func(a, b Step) int { return cmp.Compare(a.Location, b.Location) })
+	return steps
+}
+
+// explain records a step of a dry run. It does nothing otherwise.
+func (a *Alerter) explain(loc location.Location, r *recipient, check string, passed bool, format string, args ...any) {
+	a.explainStep(loc, r, Step{Check: check, Passed: passed, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (a *Alerter) explainStep(loc location.Location, r *recipient, s Step) {
+	if a.Explanation == nil {
+		return
+	}
+	s.Location = loc.Name
+	if r != nil {
+		s.Recipient = r.name
+	}
+	a.Explanation.add(s)
+}
@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// defaultHeatCooldown applies to UV and heat alerts unless uvCooldown or
+// heatCooldown (minutes) are configured, so a hot afternoon is not alerted
+// hourly.
+const defaultHeatCooldown = 4 * time.Hour
+
+var (
+	uvTags   = []string{"sunny", "robot"}
+	heatTags = []string{"hot_face", "thermometer"}
+)
+
+// heatStress is the higher of the felt temperature and the heat index.
+func heatStress(hour weather.Hour) float64 {
+	return max(hour.FeelsLikeC, hour.HeatIndexC)
+}
+
+// uvSeverity raises extreme UV, 11 and above.
+func uvSeverity(uv float64) Severity {
+	if uv >= 11 {
+		return SeverityModerate
+	}
+	return SeverityLow
+}
+
+// heatSeverity follows the heat index danger levels.
+func heatSeverity(stress float64) Severity {
+	switch {
+	case stress >= 51:
+		return SeverityHigh
+	case stress >= 39:
+		return SeverityModerate
+	default:
+		return SeverityLow
+	}
+}
+
+// heatAlerts alerts of high UV, from uvThreshold (UV index), and of heat
+// stress, from heatThreshold (°C), in the next hour during the day. Both are
+// off unless their threshold is set.
+func heatAlerts(hour weather.Hour, thresholds map[string]int) []hourAlert {
+	if hour.IsDay != 1 {
+		return nil
+	}
+
+	var alerts []hourAlert
+	if uv := thresholds["uvThreshold"]; uv > 0 && hour.UV >= float64(uv) {
+		alerts = append(alerts, hourAlert{
+			kind:     kindUV,
+			report:   message.ReportUV,
+			tags:     uvTags,
+			severity: uvSeverity(hour.UV),
+			state:    int(hour.UV),
+			cooldown: cooldownFrom(thresholds, "uvCooldown", defaultHeatCooldown),
+		})
+	}
+
+	if heat := thresholds["heatThreshold"]; heat > 0 && heatStress(hour) >= float64(heat) {
+		alerts = append(alerts, hourAlert{
+			kind:     kindHeat,
+			report:   message.ReportHeat,
+			tags:     heatTags,
+			severity: heatSeverity(heatStress(hour)),
+			state:    int(heatStress(hour)),
+			cooldown: cooldownFrom(thresholds, "heatCooldown", defaultHeatCooldown),
+		})
+	}
+	return alerts
+}
@@ -0,0 +1,189 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/rule"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// hourAlert is an alert about the next hour other than precipitation, such as
+// wind or air quality, with a notification history of its own.
+type hourAlert struct {
+	kind   string
+	report string
+	// title and message, when message is set, are used instead of the
+	// report.
+	title    string
+	message  string
+	tags     []string
+	severity Severity
+	// state is recorded with the notification. Within the cooldown the alert
+	// is only sent again if repeat, given the last state, says so, or
+	// without repeat if its state is higher than the last one.
+	state    int
+	cooldown time.Duration
+	repeat   func(last int) (bool, error)
+
+	// hysteresis alerts, from rules with an exit condition, are sent when
+	// they become active instead of after each cooldown, and stay active
+	// until exit. They are evaluated whether they match or not.
+	hysteresis bool
+	match      bool
+	exit       bool
+}
+
+// withHysteresis makes h a hysteresis alert of a rule with an exit
+// condition.
+func withHysteresis(h hourAlert, ru *rule.Rule, hour weather.Hour, thresholds map[string]int, match bool) (hourAlert, error) {
+	exit, err := ru.Exits(hour, thresholds)
+	if err != nil {
+		return hourAlert{}, err
+	}
+	h.hysteresis, h.match, h.exit = true, match, exit
+	return h, nil
+}
+
+// repeats reports whether the alert is sent again within its cooldown,
+// given the state last notified.
+func (h hourAlert) repeats(last int) (bool, error) {
+	if h.repeat != nil {
+		return h.repeat(last)
+	}
+	return last < h.state, nil
+}
+
+// transition returns whether a hysteresis alert is sent, given whether it
+// was active, and whether it is active afterwards if sent.
+func (h hourAlert) transition(active bool) (send, next bool) {
+	if active {
+		return false, !h.exit
+	}
+	return h.match, h.match
+}
+
+// enters reports whether a hysteresis alert becomes active, storing that it
+// is no longer when it exits. The caller stores that it is once it is sent.
+func (a *Alerter) enters(ctx context.Context, loc location.Location, r recipient, h hourAlert) (bool, error) {
+	active, err := a.Store.AlertActive(ctx, loc.Name, r.subscriptionID, h.kind)
+	if err != nil {
+		return false, fmt.Errorf("getting %s alert state: %w", h.kind, err)
+	}
+
+	send, next := h.transition(active)
+	a.explain(loc, &r, h.kind+" state", send, "active: %t, entered: %t, exited: %t", active, h.match, h.exit)
+	if active && !next {
+		if err := a.Store.SetAlertActive(ctx, loc.Name, r.subscriptionID, h.kind, false); err != nil {
+			return false, fmt.Errorf("storing %s alert state: %w", h.kind, err)
+		}
+	}
+	return send, nil
+}
+
+// hourAlerts returns the alerts other than precipitation whose conditions
+// the next hour meets.
+func (a *Alerter) hourAlerts(loc location.Location, r recipient, hour weather.Hour) ([]hourAlert, error) {
+	var alerts []hourAlert
+	if h, ok := windAlert(hour, r.thresholds); ok {
+		alerts = append(alerts, h)
+	}
+	if h, ok := airQualityAlert(loc, hour, r.thresholds); ok {
+		alerts = append(alerts, h)
+	}
+	alerts = append(alerts, heatAlerts(hour, r.thresholds)...)
+
+	rules, err := a.ruleAlerts(loc, r, hour)
+	if err != nil {
+		return nil, err
+	}
+	return append(alerts, rules...), nil
+}
+
+// activate stores that a hysteresis alert was sent. It does nothing for
+// other alerts.
+func (a *Alerter) activate(ctx context.Context, loc location.Location, r recipient, h hourAlert) error {
+	if !h.hysteresis {
+		return nil
+	}
+	if err := a.Store.SetAlertActive(ctx, loc.Name, r.subscriptionID, h.kind, true); err != nil {
+		return fmt.Errorf("storing %s alert state: %w", h.kind, err)
+	}
+	return nil
+}
+
+// sendHourAlert sends an hour alert unless it is quiet hours, when it is
+// dropped, or a previous one suppresses it.
+func (a *Alerter) sendHourAlert(ctx context.Context, loc location.Location, r recipient, hour *weather.Hour, isQuiet bool, h hourAlert) error {
+	if h.hysteresis {
+		send, err := a.enters(ctx, loc, r, h)
+		if err != nil || !send {
+			return err
+		}
+	} else {
+		a.explain(loc, &r, h.kind, true, "conditions met, state %d", h.state)
+	}
+	if isQuiet {
+		log.Printf("%s/%s: quiet hours, not notifying of %s.\n", loc.Name, r.name, h.kind)
+		return nil
+	}
+
+	if !h.hysteresis {
+		notify, reason, err := a.Store.ShouldNotify(ctx, loc.Name, r.subscriptionID, h.kind, h.cooldown, h.repeats)
+		if err != nil {
+			return fmt.Errorf("checking %s notification history: %w", h.kind, err)
+		}
+		a.explain(loc, &r, h.kind+" suppression", notify, "%s", reason)
+		if !notify {
+			log.Printf("%s/%s: recent %s alert, skipping notification.\n", loc.Name, r.name, h.kind)
+			return nil
+		}
+	}
+
+	templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if err != nil {
+		return err
+	}
+
+	data := message.HourData{Name: loc.Name, Hour: *hour}
+	title, body := h.title, ""
+	if h.message != "" {
+		body, err = templates.Locale.Execute(h.kind, h.message, data)
+	} else {
+		title, body, err = templates.Locale.Report(h.report, data)
+	}
+	if err != nil {
+		return fmt.Errorf("rendering %s alert: %w", h.kind, err)
+	}
+
+	msg := ntfy.Message{Title: title, Body: body, Tags: h.tags, Priority: h.severity.Priority(), Icon: a.IconURL}
+	if err := a.send(ctx, loc, r, msg); err != nil {
+		return fmt.Errorf("sending %s alert: %w", h.kind, err)
+	}
+	a.fire(h, hour.Time)
+
+	if err := a.Store.RecordNotification(ctx, loc.Name, r.subscriptionID, h.kind, h.state); err != nil {
+		return fmt.Errorf("recording %s alert: %w", h.kind, err)
+	}
+	return a.activate(ctx, loc, r, h)
+}
+
+// fire tells a backtest of a next-hour notification sent.
+func (a *Alerter) fire(h hourAlert, hour string) {
+	if a.fired != nil {
+		a.fired(Fired{Kind: h.kind, Report: h.report, Hour: hour, State: h.state, Severity: h.severity.String()})
+	}
+}
+
+// cooldownFrom returns the cooldown configured in minutes under key, or def.
+func cooldownFrom(thresholds map[string]int, key string, def time.Duration) time.Duration {
+	if minutes := thresholds[key]; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return def
+}
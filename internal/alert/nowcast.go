@@ -0,0 +1,82 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/nowcast"
+)
+
+const kindNowcast = "nowcast"
+
+// defaultNowcastLead is how long before rain starts it is alerted, unless
+// nowcastLead (minutes) is configured.
+const defaultNowcastLead = 15 * time.Minute
+
+// nowcastWindow is how far past the lead time rain is alerted. Runs are
+// hourly, later rain is left to the next run's fresher nowcast.
+const nowcastWindow = time.Hour
+
+var nowcastTags = []string{"umbrella", "stopwatch"}
+
+// alertNowcast alerts once of rain starting within the next hour, delivered
+// by ntfy ahead of it by the lead time. It is alerted again once the nowcast
+// has been dry for the next hour.
+func (a *Alerter) alertNowcast(ctx context.Context, loc location.Location, r recipient, n *nowcast.Nowcast, now time.Time) error {
+	lead := cooldownFrom(r.thresholds, "nowcastLead", defaultNowcastLead)
+	rain, ok := n.NextRain(now)
+	match := ok && rain.Start.Before(now.Add(lead+nowcastWindow))
+
+	h := hourAlert{kind: kindNowcast, hysteresis: true, match: match, exit: !match}
+	send, err := a.enters(ctx, loc, r, h)
+	if err != nil || !send {
+		return err
+	}
+
+	deliverAt := rain.Start.Add(-lead)
+	if deliverAt.Before(now) {
+		deliverAt = now
+	}
+	if _, isQuiet := location.ActiveQuietHours(r.quietHours, deliverAt.In(now.Location())); isQuiet {
+		a.explain(loc, &r, "quiet hours", false, "quiet hours at %s", deliverAt.In(now.Location()).Format(time.TimeOnly))
+		log.Printf("%s/%s: quiet hours, not notifying of %s.\n", loc.Name, r.name, kindNowcast)
+		return nil
+	}
+
+	templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if err != nil {
+		return err
+	}
+	title, body, err := templates.Locale.Report(message.ReportNowcast, message.NowcastData{
+		Name:    loc.Name,
+		In:      roundMinutes(rain.Start.Sub(deliverAt)),
+		Lasts:   max(roundMinutes(rain.Duration), 5),
+		Longer:  rain.Open,
+		TotalMM: rain.TotalMM,
+	})
+	if err != nil {
+		return fmt.Errorf("rendering %s alert: %w", kindNowcast, err)
+	}
+
+	msg := a.nextHourNotification(title, nowcastTags, SeverityLow, body)
+	if delay := deliverAt.Sub(now).Round(time.Minute); delay > 0 {
+		msg.Delay = fmt.Sprintf("%dm", int(delay.Minutes()))
+	}
+	if err := a.send(ctx, loc, r, msg); err != nil {
+		return fmt.Errorf("sending %s alert: %w", kindNowcast, err)
+	}
+
+	if err := a.Store.RecordNotification(ctx, loc.Name, r.subscriptionID, kindNowcast, roundMinutes(rain.Duration)); err != nil {
+		return fmt.Errorf("recording %s alert: %w", kindNowcast, err)
+	}
+	return a.activate(ctx, loc, r, h)
+}
+
+// roundMinutes rounds d to 5 minutes, as nowcasts are approximate.
+func roundMinutes(d time.Duration) int {
+	return int(d.Round(5 * time.Minute).Minutes())
+}
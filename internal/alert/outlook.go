@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+const outlookKind = "outlook"
+
+// forecastDays is how many days to fetch: today only, unless a recipient's
+// outlook is due, which needs the following days too.
+func forecastDays(recipients []recipient, now time.Time) int {
+	days := 1
+	for _, r := range recipients {
+		if r.outlook != nil && r.outlook.Due(now) {
+			days = max(days, r.outlook.Days+1)
+		}
+	}
+	return days
+}
+
+// sendOutlook sends the coming days' rain, starting tomorrow, once on each
+// day the recipient's outlook is due.
+func (a *Alerter) sendOutlook(ctx context.Context, loc location.Location, r recipient, weatherData *weather.WeatherResponse, now time.Time) error {
+	if r.outlook == nil || !r.outlook.Due(now) || len(weatherData.Forecast.ForecastDay) < 2 {
+		return nil
+	}
+
+	day := now.Format(time.DateOnly)
+	notified, err := a.DB.DailyNotified(ctx, loc.Name, r.subscriptionID, outlookKind, day)
+	if err != nil {
+		return fmt.Errorf("checking outlook history: %w", err)
+	}
+	if notified {
+		return nil
+	}
+
+	templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if err != nil {
+		return err
+	}
+
+	data := message.OutlookData{Name: loc.Name}
+	for _, fd := range weatherData.Forecast.ForecastDay[1:min(len(weatherData.Forecast.ForecastDay), r.outlook.Days+1)] {
+		data.Days = append(data.Days, message.OutlookDay{
+			Date:    fd.Date,
+			Summary: weather.Summarize(fd.Hour, r.thresholds["drizzleThreshold"]),
+		})
+	}
+	title, body, err := templates.Locale.Report(message.ReportOutlook, data)
+	if err != nil {
+		return fmt.Errorf("rendering outlook: %w", err)
+	}
+
+	msg := ntfy.Message{Title: title, Body: body, Tags: []string{"calendar", "cloud_with_rain"}, Priority: ntfy.PriorityLow, Icon: a.IconURL}
+	if err := a.send(ctx, loc, r, msg); err != nil {
+		return fmt.Errorf("sending outlook: %w", err)
+	}
+	log.Printf("%s/%s: outlook sent.\n", loc.Name, r.name)
+
+	if err := a.DB.RecordDailyNotification(ctx, loc.Name, r.subscriptionID, outlookKind, day); err != nil {
+		return fmt.Errorf("recording outlook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/platform/database"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// resolve returns what the weather provider is asked for a location and,
+// when known, the place it is. Configured coordinates are used as is,
+// geocoded locations are searched once and cached in the database.
+func (a *Alerter) resolve(ctx context.Context, loc location.Location) (string, *database.Place, error) {
+	if lat, lon, ok := loc.Coordinates(); ok {
+		return loc.Query, &database.Place{Name: loc.Name, Lat: lat, Lon: lon}, nil
+	}
+	if !loc.Geocode {
+		return loc.Query, nil, nil
+	}
+
+	search := strings.Join([]string{loc.Query, loc.Region, loc.Country}, "|")
+	place, err := a.DB.GetPlace(ctx, search)
+	if err != nil {
+		return "", nil, fmt.Errorf("getting place: %w", err)
+	}
+	if place == nil {
+		if place, err = a.geocode(ctx, loc); err != nil {
+			return "", nil, fmt.Errorf("geocoding %q: %w", loc.Query, err)
+		}
+		if err := a.DB.RecordPlace(ctx, search, *place); err != nil {
+			return "", nil, fmt.Errorf("recording place: %w", err)
+		}
+		log.Printf("%s: %q resolved to %s.\n", loc.Name, loc.Query, placeName(*place))
+	}
+
+	query := location.FormatCoordinates(place.Lat, place.Lon)
+	a.explain(loc, nil, "place", true, "%s at %s", placeName(*place), query)
+	return query, place, nil
+}
+
+// geocode searches the location's query, keeping the first place in its
+// region and country.
+func (a *Alerter) geocode(ctx context.Context, loc location.Location) (*database.Place, error) {
+	places, err := a.Weather.Search(ctx, loc.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := weather.MatchPlaces(places, loc.Region, loc.Country)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no place found in %d results", len(places))
+	}
+	if len(matches) > 1 {
+		names := make([]string, len(matches))
+		for i, p := range matches {
+			names[i] = p.String()
+		}
+		log.Printf("%s: %q is ambiguous (%s), using the first; set region or country to choose.\n", loc.Name, loc.Query, strings.Join(names, "; "))
+	}
+
+	p := matches[0]
+	return &database.Place{Name: p.Name, Region: p.Region, Country: p.Country, Lat: p.Lat, Lon: p.Lon}, nil
+}
+
+// placeName returns the canonical name of a place.
+func placeName(p database.Place) string {
+	return weather.Place{Name: p.Name, Region: p.Region, Country: p.Country}.String()
+}
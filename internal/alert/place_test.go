@@ -0,0 +1,104 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/imedgar/rain-alert/internal/location"
+)
+
+func TestCheckAndAlertGeocode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	searchBody := []byte(`[
+		{"id": 1, "name": "Springfield", "region": "Missouri", "country": "United States of America", "lat": 37.22, "lon": -93.3},
+		{"id": 2, "name": "Springfield", "region": "Illinois", "country": "United States of America", "lat": 39.8, "lon": -89.64}
+	]`)
+	weatherBody := forecastBody(0)
+	var requests []string
+	mockHTTPClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			body := weatherBody
+			if req.URL.Path == "/search.json" {
+				body = searchBody
+			}
+			requests = append(requests, req.URL.Path+" "+req.URL.Query().Get("q"))
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	alerter := newTestAlerter(t, mockHTTPClient, db)
+	alerter.Weather.SearchURL = "http://weather.com/search.json"
+	loc := location.Location{Name: "home", Query: "Springfield", Region: "Illinois", Geocode: true, Timezone: "UTC"}
+
+	expect := func() {
+		mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).AddRow("drizzleThreshold", "50"))
+		mock.ExpectQuery("FROM subscriptions").WithArgs("home").WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+	}
+
+	t.Run("Resolves and caches the place", func(t *testing.T) {
+		requests = nil
+		expect()
+		mock.ExpectQuery("FROM places").WithArgs("Springfield|Illinois|").WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec("INSERT INTO places").
+			WithArgs("Springfield|Illinois|", "Springfield", "Illinois", "United States of America", 39.8, -89.64, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+
+		if err := alerter.CheckAndAlert(context.Background(), loc); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := []string{"/search.json Springfield", " 39.8,-89.64"}
+		if !slices.Equal(requests, expected) {
+			t.Errorf("expected requests %q, got %q", expected, requests)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Uses the cached place", func(t *testing.T) {
+		requests = nil
+		expect()
+		mock.ExpectQuery("FROM places").WithArgs("Springfield|Illinois|").
+			WillReturnRows(sqlmock.NewRows([]string{"name", "region", "country", "lat", "lon"}).
+				AddRow("Springfield", "Illinois", "United States of America", 39.8, -89.64))
+		mock.ExpectQuery("FROM held_notifications").WithArgs("home", 0).WillReturnRows(sqlmock.NewRows(heldColumns))
+
+		if err := alerter.CheckAndAlert(context.Background(), loc); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		expected := []string{" 39.8,-89.64"}
+		if !slices.Equal(requests, expected) {
+			t.Errorf("expected requests %q, got %q", expected, requests)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("No place in the region", func(t *testing.T) {
+		expect()
+		mock.ExpectQuery("FROM places").WithArgs("Springfield|Oregon|").WillReturnError(sql.ErrNoRows)
+
+		oregon := loc
+		oregon.Region = "Oregon"
+		if err := alerter.CheckAndAlert(context.Background(), oregon); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/platform/database"
+)
+
+// recipient is one audience for a location's alerts, with its own
+// thresholds and notification history.
+type recipient struct {
+	name            string
+	subscriptionID  int64 // 0 for the location's own topics
+	topics          []string
+	thresholds      map[string]int
+	messageSet      string
+	locale          string
+	quietHours      []location.QuietHours
+	commutes        []location.Commute
+	disableNextHour bool
+	digestAt        string
+	outlook         *location.Outlook
+}
+
+// recipients returns the location's own audience followed by its subscribers.
+// A subscription with invalid settings, including a locale or message set
+// without templates, is logged and skipped, so it does not keep the others
+// from being alerted.
+func (a *Alerter) recipients(ctx context.Context, loc location.Location, defaults map[string]int) ([]recipient, error) {
+	var recipients []recipient
+	if !loc.SubscribersOnly {
+		recipients = append(recipients, locationRecipient(loc, loc.ThresholdsFrom(defaults)))
+	}
+
+	subs, err := a.DB.GetSubscriptions(ctx, loc.Name)
+	if err != nil {
+		return nil, fmt.Errorf("getting subscriptions: %w", err)
+	}
+	for _, s := range subs {
+		r, err := subscriberRecipient(loc, defaults, s)
+		if err == nil {
+			err = a.checkMessages(ctx, r)
+		}
+		if err != nil {
+			log.Printf("%s: skipping subscription %d: %v\n", loc.Name, s.ID, err)
+			continue
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, nil
+}
+
+// checkMessages loads a recipient's templates, returning only the errors of
+// an unsupported locale or unknown message set: others, such as the store
+// failing, are left to the alerts.
+func (a *Alerter) checkMessages(ctx context.Context, r recipient) error {
+	_, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+	if errors.Is(err, message.ErrUnsupportedLocale) || errors.Is(err, message.ErrUnknownSet) {
+		return err
+	}
+	return nil
+}
+
+// locationRecipient is the location's own audience.
+func locationRecipient(loc location.Location, thresholds map[string]int) recipient {
+	return recipient{
+		name:            loc.Name,
+		topics:          loc.Topics,
+		thresholds:      thresholds,
+		quietHours:      loc.QuietHours,
+		commutes:        loc.Commutes,
+		disableNextHour: loc.DisableNextHour,
+		digestAt:        loc.DigestAt,
+		outlook:         loc.Outlook,
+	}
+}
+
+// subscriberRecipient applies a subscription's settings over the location's.
+func subscriberRecipient(loc location.Location, defaults map[string]int, s database.Subscription) (recipient, error) {
+	thresholds := loc.ThresholdsFrom(defaults)
+	maps.Copy(thresholds, s.Thresholds)
+
+	quietHours, err := location.ParseQuietHours(s.QuietHours)
+	if err != nil {
+		return recipient{}, err
+	}
+	if quietHours == nil {
+		quietHours = loc.QuietHours
+	}
+
+	commutes, err := location.ParseCommutes(s.Commutes)
+	if err != nil {
+		return recipient{}, err
+	}
+	if commutes == nil {
+		commutes = loc.Commutes
+	}
+
+	outlook, err := location.ParseOutlook(s.Outlook)
+	if err != nil {
+		return recipient{}, err
+	}
+	if outlook == nil {
+		outlook = loc.Outlook
+	}
+
+	return recipient{
+		name:            s.UserName,
+		subscriptionID:  s.ID,
+		topics:          []string{s.Topic},
+		thresholds:      thresholds,
+		messageSet:      s.MessageSet,
+		locale:          s.Locale,
+		quietHours:      quietHours,
+		commutes:        commutes,
+		disableNextHour: s.DisableNextHour || loc.DisableNextHour,
+		digestAt:        cmp.Or(s.DigestAt, loc.DigestAt),
+		outlook:         outlook,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/platform/database"
+	"github.com/imedgar/rain-alert/internal/schedule"
+)
+
+func TestSubscriberRecipient(t *testing.T) {
+	loc := location.Location{
+		Name:       "home",
+		Thresholds: map[string]int{"drizzleThreshold": 55},
+		QuietHours: []location.QuietHours{{Window: schedule.Window{Start: "22:00", End: "07:00"}}},
+	}
+	defaults := map[string]int{"drizzleThreshold": 50, "rainBeforeThreshold": 70}
+
+	t.Run("Falls back to the location", func(t *testing.T) {
+		r, err := subscriberRecipient(loc, defaults, database.Subscription{ID: 1, Topic: "ana"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.thresholds["drizzleThreshold"] != 55 || len(r.quietHours) != 1 {
+			t.Errorf("unexpected recipient %+v", r)
+		}
+	})
+
+	t.Run("Subscription overrides", func(t *testing.T) {
+		s := database.Subscription{
+			ID:         2,
+			Topic:      "jonas",
+			Thresholds: map[string]int{"drizzleThreshold": 65},
+			QuietHours: `[{"days": "weekends", "start": "21:00", "end": "10:00", "hold": true}]`,
+		}
+		r, err := subscriberRecipient(loc, defaults, s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.thresholds["drizzleThreshold"] != 65 || r.thresholds["rainBeforeThreshold"] != 70 {
+			t.Errorf("unexpected thresholds %v", r.thresholds)
+		}
+		if len(r.quietHours) != 1 || !r.quietHours[0].Hold || r.quietHours[0].Days != schedule.Weekends {
+			t.Errorf("unexpected quiet hours %+v", r.quietHours)
+		}
+	})
+
+	t.Run("Invalid quiet hours", func(t *testing.T) {
+		if _, err := subscriberRecipient(loc, defaults, database.Subscription{QuietHours: "nope"}); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
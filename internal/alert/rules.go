@@ -0,0 +1,97 @@
+package alert
+
+import (
+	"maps"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/rule"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+var (
+	defaultRainRule    = rule.MustCompile(rule.Definition{Name: rule.Rain, When: rule.DefaultRain})
+	hysteresisRainRule = rule.MustCompile(rule.Definition{Name: rule.Rain, When: rule.DefaultRain, Exit: rule.DefaultRainExit})
+)
+
+// rainRule is the condition of the built-in rain alert, with an exit
+// condition when drizzleExitThreshold is configured.
+func (a *Alerter) rainRule(thresholds map[string]int) *rule.Rule {
+	if r, ok := rule.Find(a.Rules, rule.Rain); ok {
+		return r
+	}
+	if thresholds["drizzleExitThreshold"] > 0 {
+		return hysteresisRainRule
+	}
+	return defaultRainRule
+}
+
+// rainThresholds returns the thresholds the rain rule is evaluated with.
+// User rules are checked against the configured thresholds when loaded, the
+// built-in ones read an unset threshold as 0, as the rain alert always has.
+func (a *Alerter) rainThresholds(thresholds map[string]int, rain *rule.Rule) map[string]int {
+	if _, ok := rule.Find(a.Rules, rule.Rain); ok {
+		return thresholds
+	}
+	for _, name := range rain.Thresholds {
+		if _, ok := thresholds[name]; !ok {
+			thresholds = maps.Clone(thresholds)
+			thresholds[name] = 0
+		}
+	}
+	return thresholds
+}
+
+func ruleSeverity(severity string) Severity {
+	switch severity {
+	case "moderate":
+		return SeverityModerate
+	case "high":
+		return SeverityHigh
+	default:
+		return SeverityLow
+	}
+}
+
+// ruleRepeat sends a rule's alert again within its cooldown when its repeat
+// condition holds. The state of rule alerts is the chance of precipitation.
+func ruleRepeat(ru *rule.Rule, hour weather.Hour, thresholds map[string]int) func(last int) (bool, error) {
+	return func(last int) (bool, error) {
+		return ru.Repeats(hour, thresholds, last)
+	}
+}
+
+// ruleAlerts returns the user-defined rules matching the next hour, each
+// with its own notification history and cooldown.
+func (a *Alerter) ruleAlerts(loc location.Location, r recipient, hour weather.Hour) ([]hourAlert, error) {
+	var alerts []hourAlert
+	for _, ru := range a.Rules {
+		if ru.Name == rule.Rain {
+			continue
+		}
+
+		match, err := ru.Match(hour, r.thresholds)
+		if err != nil {
+			return nil, err
+		}
+		h := hourAlert{
+			kind:     "rule:" + ru.Name,
+			title:    ru.Title,
+			message:  ru.Message,
+			tags:     []string{"bell", ru.Name},
+			severity: ruleSeverity(ru.Severity),
+			state:    hour.Chance(),
+			cooldown: ru.Cooldown,
+			repeat:   ruleRepeat(ru, hour, r.thresholds),
+		}
+		if ru.Hysteresis() {
+			if h, err = withHysteresis(h, ru, hour, r.thresholds, match); err != nil {
+				return nil, err
+			}
+		} else if !match {
+			a.explain(loc, &r, h.kind, false, "%s not met", ru.When)
+			continue
+		}
+		alerts = append(alerts, h)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,81 @@
+package alert
+
+import (
+	"cmp"
+
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// Severity ranks how bad the forecast hour is, so notifications can be
+// prioritised accordingly.
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityModerate
+	SeverityHigh
+)
+
+// heavyRainMM is the hourly precipitation above which rain is considered heavy.
+const heavyRainMM = 4.0
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityModerate:
+		return "moderate"
+	case SeverityHigh:
+		return "high"
+	default:
+		return "low"
+	}
+}
+
+// Priority maps the severity to an ntfy priority.
+func (s Severity) Priority() ntfy.Priority {
+	switch s {
+	case SeverityModerate:
+		return ntfy.PriorityHigh
+	case SeverityHigh:
+		return ntfy.PriorityUrgent
+	default:
+		return ntfy.PriorityDefault
+	}
+}
+
+// rainSeverity is moderate from rainHighThreshold, which defaults to
+// rainBeforeThreshold, and high when the rain is also heavy.
+func rainSeverity(chanceOfRain int, precipMM float64, thresholds map[string]int) Severity {
+	high := cmp.Or(thresholds["rainHighThreshold"], thresholds["rainBeforeThreshold"])
+	switch {
+	case chanceOfRain >= high && precipMM >= heavyRainMM:
+		return SeverityHigh
+	case chanceOfRain >= high:
+		return SeverityModerate
+	default:
+		return SeverityLow
+	}
+}
+
+// precipitationSeverity raises the severity of thunderstorms and freezing
+// rain, which are hazardous whatever the amount.
+func precipitationSeverity(precipitation weather.Precipitation, severity Severity) Severity {
+	switch precipitation {
+	case weather.PrecipitationFreezingRain:
+		return SeverityHigh
+	case weather.PrecipitationThunder:
+		return max(severity, SeverityModerate)
+	default:
+		return severity
+	}
+}
+
+// precipitationTags are the ntfy tags, shown as emojis, of each type of
+// precipitation.
+var precipitationTags = map[weather.Precipitation][]string{
+	weather.PrecipitationRain:         {"umbrella", "robot"},
+	weather.PrecipitationSnow:         {"snowflake", "robot"},
+	weather.PrecipitationSleet:        {"cloud_with_snow", "umbrella"},
+	weather.PrecipitationThunder:      {"cloud_with_lightning_and_rain", "zap"},
+	weather.PrecipitationFreezingRain: {"ice_cube", "warning"},
+}
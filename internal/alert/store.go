@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/platform/database"
+)
+
+// Store keeps the notification history, held notifications and alert states
+// next-hour alerts are decided on. *database.DB is the default
+// implementation, backtests use a memoryStore.
+type Store interface {
+	ShouldNotify(ctx context.Context, location string, subscriptionID int64, kind string, cooldown time.Duration, repeat func(state int) (bool, error)) (notify bool, reason string, err error)
+	RecordNotification(ctx context.Context, location string, subscriptionID int64, kind string, state int) error
+	AlertActive(ctx context.Context, location string, subscriptionID int64, kind string) (bool, error)
+	SetAlertActive(ctx context.Context, location string, subscriptionID int64, kind string, active bool) error
+	HoldNotification(ctx context.Context, location string, subscriptionID int64, n database.HeldNotification) error
+	GetHeldNotifications(ctx context.Context, location string, subscriptionID int64) ([]database.HeldNotification, error)
+	ClearHeldNotifications(ctx context.Context, location string, subscriptionID int64) error
+}
+
+// storeKey identifies a kind of alert for one subscription at a location.
+type storeKey struct {
+	location       string
+	subscriptionID int64
+	kind           string
+}
+
+type storedNotification struct {
+	state int
+	at    time.Time
+}
+
+// memoryStore is a Store kept in memory, with a clock of its own set by the
+// caller.
+type memoryStore struct {
+	now           time.Time
+	notifications map[storeKey]storedNotification
+	active        map[storeKey]bool
+	held          map[storeKey][]database.HeldNotification
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		notifications: make(map[storeKey]storedNotification),
+		active:        make(map[storeKey]bool),
+		held:          make(map[storeKey][]database.HeldNotification),
+	}
+}
+
+func (s *memoryStore) ShouldNotify(ctx context.Context, location string, subscriptionID int64, kind string, cooldown time.Duration, repeat func(state int) (bool, error)) (bool, string, error) {
+	last, ok := s.notifications[storeKey{location, subscriptionID, kind}]
+	if !ok {
+		return true, fmt.Sprintf("no previous %s notification", kind), nil
+	}
+	return database.CheckCooldown(kind, last.state, s.now.Sub(last.at), cooldown, repeat)
+}
+
+func (s *memoryStore) RecordNotification(ctx context.Context, location string, subscriptionID int64, kind string, state int) error {
+	s.notifications[storeKey{location, subscriptionID, kind}] = storedNotification{state: state, at: s.now}
+	return nil
+}
+
+func (s *memoryStore) AlertActive(ctx context.Context, location string, subscriptionID int64, kind string) (bool, error) {
+	return s.active[storeKey{location, subscriptionID, kind}], nil
+}
+
+func (s *memoryStore) SetAlertActive(ctx context.Context, location string, subscriptionID int64, kind string, active bool) error {
+	s.active[storeKey{location, subscriptionID, kind}] = active
+	return nil
+}
+
+func (s *memoryStore) HoldNotification(ctx context.Context, location string, subscriptionID int64, n database.HeldNotification) error {
+	key := storeKey{location: location, subscriptionID: subscriptionID}
+	n.CreatedAt = s.now
+	s.held[key] = append(s.held[key], n)
+	return nil
+}
+
+func (s *memoryStore) GetHeldNotifications(ctx context.Context, location string, subscriptionID int64) ([]database.HeldNotification, error) {
+	return s.held[storeKey{location: location, subscriptionID: subscriptionID}], nil
+}
+
+func (s *memoryStore) ClearHeldNotifications(ctx context.Context, location string, subscriptionID int64) error {
+	delete(s.held, storeKey{location: location, subscriptionID: subscriptionID})
+	return nil
+}
@@ -0,0 +1,84 @@
+package alert
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// timezone returns the timezone all of a location's time math uses: the
+// configured one, or else the forecast's. A configured timezone at odds
+// with the forecast's is kept but logged, as either it is wrong or the
+// query resolved to another place.
+func (a *Alerter) timezone(loc location.Location, w *weather.WeatherResponse) (*time.Location, error) {
+	forecastTZ, forecastErr := w.Timezone()
+	if loc.Timezone == "" {
+		if forecastErr != nil {
+			return nil, fmt.Errorf("no timezone configured: %w", forecastErr)
+		}
+		a.explain(loc, nil, "timezone", true, "%s, from the forecast", forecastTZ)
+		return forecastTZ, nil
+	}
+
+	tz, err := time.LoadLocation(loc.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	if forecastErr == nil && !sameZone(tz, forecastTZ) {
+		log.Printf("%s: configured timezone %s does not match the forecast's %s, using %s.\n", loc.Name, tz, forecastTZ, tz)
+		a.explain(loc, nil, "timezone", false, "configured %s does not match the forecast's %s", tz, forecastTZ)
+		return tz, nil
+	}
+	a.explain(loc, nil, "timezone", true, "%s, configured", tz)
+	return tz, nil
+}
+
+// sameZone reports whether two timezones are the same zone, resolving
+// aliases such as Asia/Calcutta for Asia/Kolkata. Offsets are not compared:
+// Europe/Madrid and Europe/Berlin always share one, and America/Denver and
+// America/Phoenix do for half the year.
+func sameZone(a, b *time.Location) bool {
+	return canonicalZone(a.String()) == canonicalZone(b.String())
+}
+
+func canonicalZone(name string) string {
+	if canonical, ok := zoneAliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// zoneAliases maps common backward-compatible zone names of the tz database
+// to the canonical ones.
+var zoneAliases = map[string]string{
+	"Asia/Calcutta":        "Asia/Kolkata",
+	"Asia/Saigon":          "Asia/Ho_Chi_Minh",
+	"Asia/Katmandu":        "Asia/Kathmandu",
+	"Asia/Rangoon":         "Asia/Yangon",
+	"Asia/Chongqing":       "Asia/Shanghai",
+	"Asia/Istanbul":        "Europe/Istanbul",
+	"Turkey":               "Europe/Istanbul",
+	"Europe/Kiev":          "Europe/Kyiv",
+	"Europe/Belfast":       "Europe/London",
+	"GB":                   "Europe/London",
+	"America/Buenos_Aires": "America/Argentina/Buenos_Aires",
+	"America/Montreal":     "America/Toronto",
+	"US/Eastern":           "America/New_York",
+	"US/Central":           "America/Chicago",
+	"US/Mountain":          "America/Denver",
+	"US/Arizona":           "America/Phoenix",
+	"US/Pacific":           "America/Los_Angeles",
+	"Canada/Eastern":       "America/Toronto",
+	"Australia/ACT":        "Australia/Sydney",
+	"Australia/NSW":        "Australia/Sydney",
+	"NZ":                   "Pacific/Auckland",
+	"Japan":                "Asia/Tokyo",
+	"Etc/UTC":              "UTC",
+	"Etc/GMT":              "UTC",
+	"GMT":                  "UTC",
+	"Universal":            "UTC",
+	"Zulu":                 "UTC",
+}
@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+func TestTimezone(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		configured string
+		forecast   string
+		expected   string
+		passed     bool
+		fails      bool
+	}{
+		{name: "From the forecast", forecast: "Europe/Madrid", expected: "Europe/Madrid", passed: true},
+		{name: "Configured", configured: "Europe/Madrid", forecast: "Europe/Madrid", expected: "Europe/Madrid", passed: true},
+		{name: "Alias", configured: "Asia/Calcutta", forecast: "Asia/Kolkata", expected: "Asia/Calcutta", passed: true},
+		{name: "Mismatch keeps the configured", configured: "Asia/Tokyo", forecast: "Europe/Madrid", expected: "Asia/Tokyo"},
+		{name: "Same offset, other zone", configured: "Europe/Berlin", forecast: "Europe/Madrid", expected: "Europe/Berlin"},
+		{name: "Offsets drifting apart", configured: "America/Denver", forecast: "America/Phoenix", expected: "America/Denver"},
+		{name: "Configured without a forecast timezone", configured: "Asia/Tokyo", expected: "Asia/Tokyo", passed: true},
+		{name: "Neither", fails: true},
+		{name: "Invalid", configured: "Mars/Olympus", forecast: "Europe/Madrid", fails: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			alerter := &Alerter{Explanation: &Explanation{}}
+			w := &weather.WeatherResponse{}
+			w.Location.TzID = tt.forecast
+
+			tz, err := alerter.timezone(location.Location{Name: "home", Timezone: tt.configured}, w)
+			if tt.fails {
+				if err == nil {
+					t.Error("expected an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tz.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, tz)
+			}
+			if steps := alerter.Explanation.Steps(); len(steps) != 1 || steps[0].Passed != tt.passed {
+				t.Errorf("expected a timezone step passed=%t, got %+v", tt.passed, steps)
+			}
+		})
+	}
+}
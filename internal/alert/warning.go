@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/location"
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/platform/ntfy"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// forwardWarnings forwards government weather alerts that are new, or in a
+// version not forwarded yet, regardless of the rain thresholds.
+func (a *Alerter) forwardWarnings(ctx context.Context, loc location.Location, r recipient, weatherData *weather.WeatherResponse, now time.Time) error {
+	for _, w := range weatherData.Alerts.Alert {
+		if w.Expired(now) {
+			continue
+		}
+
+		id, version := w.ID(), w.Version()
+		forwarded, err := a.DB.AlertVersions(ctx, loc.Name, r.subscriptionID, id)
+		if err != nil {
+			return fmt.Errorf("checking alert history: %w", err)
+		}
+		if slices.Contains(forwarded, version) {
+			continue
+		}
+
+		templates, err := a.Messages.Get(ctx, r.messageSet, r.locale)
+		if err != nil {
+			return err
+		}
+
+		title, body, err := templates.Locale.Report(message.ReportWarning, message.WarningData{Name: loc.Name, Updated: len(forwarded) > 0, Alert: w})
+		if err != nil {
+			return fmt.Errorf("rendering warning: %w", err)
+		}
+
+		if err := a.send(ctx, loc, r, a.warningNotification(title, body, w)); err != nil {
+			return fmt.Errorf("sending warning: %w", err)
+		}
+		log.Printf("%s/%s: weather warning %q forwarded.\n", loc.Name, r.name, w.Event)
+
+		if err := a.DB.RecordAlert(ctx, loc.Name, r.subscriptionID, id, version); err != nil {
+			return fmt.Errorf("recording warning: %w", err)
+		}
+	}
+	return nil
+}
+
+// warningNotification sends warnings with high priority, urgent when the
+// issuer rates them extreme.
+func (a *Alerter) warningNotification(title, body string, w weather.Alert) ntfy.Message {
+	priority := ntfy.PriorityHigh
+	if w.Severity == "Extreme" {
+		priority = ntfy.PriorityUrgent
+	}
+	return ntfy.Message{Title: title, Body: body, Tags: []string{"warning"}, Priority: priority, Icon: a.IconURL}
+}
@@ -0,0 +1,35 @@
+package alert
+
+import (
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+var (
+	windTags     = []string{"dash", "robot"}
+	rainWindTags = []string{"umbrella", "dash"}
+)
+
+// windy reports whether the sustained wind or the gusts of an hour reach the
+// windThreshold or gustThreshold (km/h). Unset thresholds are ignored.
+func windy(hour weather.Hour, thresholds map[string]int) bool {
+	wind, gust := thresholds["windThreshold"], thresholds["gustThreshold"]
+	return (wind > 0 && hour.WindKPH >= float64(wind)) || (gust > 0 && hour.GustKPH >= float64(gust))
+}
+
+// windAlert alerts of strong wind in the next hour when it is dry. Rain with
+// strong wind is alerted as such by the rain alert instead.
+func windAlert(hour weather.Hour, thresholds map[string]int) (hourAlert, bool) {
+	if !windy(hour, thresholds) || hour.Chance() >= thresholds["drizzleThreshold"] {
+		return hourAlert{}, false
+	}
+
+	return hourAlert{
+		kind:     kindWind,
+		report:   message.ReportWind,
+		tags:     windTags,
+		severity: SeverityLow,
+		state:    int(hour.GustKPH),
+		cooldown: cooldown,
+	}, true
+}
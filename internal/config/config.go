@@ -2,17 +2,37 @@ package config
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/imedgar/rain-alert/internal/location"
 	"github.com/sethvargo/go-envconfig"
 )
 
 type Config struct {
-	WeatherApiKey         string `env:"WEATHER_API_KEY,required"`
-	PushNotificationTopic string `env:"PUSH_NOTIFICATION_TOPIC,required"`
-	DatabaseUrl           string `env:"DB_URL,required"`
-	DatabaseToken         string `env:"DB_TOKEN,required"`
-	Location              string `env:"LOCATION,required"`
-	Timezone              string `env:"TIMEZONE,required"`
+	WeatherApiKey         string        `env:"WEATHER_API_KEY,required"`
+	PushNotificationTopic string        `env:"PUSH_NOTIFICATION_TOPIC,required"`
+	NtfyURL               string        `env:"NTFY_URL,default=https://ntfy.sh"`
+	NtfyToken             string        `env:"NTFY_TOKEN"`
+	NtfyUsername          string        `env:"NTFY_USERNAME"`
+	NtfyPassword          string        `env:"NTFY_PASSWORD"`
+	DatabaseUrl           string        `env:"DB_URL,required"`
+	DatabaseToken         string        `env:"DB_TOKEN,required"`
+	Location              string        `env:"LOCATION"`
+	Timezone              string        `env:"TIMEZONE"`
+	LocationsFile         string        `env:"LOCATIONS_FILE"`
+	RadarURL              string        `env:"RADAR_URL"`
+	IconURL               string        `env:"ICON_URL"`
+	MessageSet            string        `env:"MESSAGE_SET,default=fun"`
+	Locale                string        `env:"LOCALE,default=en"`
+	MessageStrategy       string        `env:"MESSAGE_STRATEGY,default=random"`
+	MessageNoRepeat       int           `env:"MESSAGE_NO_REPEAT,default=3"`
+	MessageTemplatesFile  string        `env:"MESSAGE_TEMPLATES_FILE"`
+	RulesFile             string        `env:"RULES_FILE"`
+	RecordSnapshots       bool          `env:"RECORD_SNAPSHOTS"`
+	NowcastProvider       string        `env:"NOWCAST_PROVIDER"`
+	HTTPTimeout           time.Duration `env:"HTTP_TIMEOUT,default=10s"`
+	HTTPRetries           int           `env:"HTTP_RETRIES,default=3"`
 }
 
 func NewConfig(ctx context.Context) (*Config, error) {
@@ -21,4 +41,16 @@ func NewConfig(ctx context.Context) (*Config, error) {
 		return nil, err
 	}
 	return &c, nil
-}
\ No newline at end of file
+}
+
+// Locations returns the locations from LOCATIONS_FILE, or the single
+// location given by LOCATION and, optionally, TIMEZONE.
+func (c *Config) Locations() ([]location.Location, error) {
+	if c.LocationsFile != "" {
+		return location.LoadFile(c.LocationsFile)
+	}
+	if c.Location == "" {
+		return nil, errors.New("either LOCATIONS_FILE or LOCATION must be set")
+	}
+	return []location.Location{{Name: c.Location, Query: c.Location, Timezone: c.Timezone}}, nil
+}
@@ -49,6 +49,9 @@ func TestNewConfig(t *testing.T) {
 		if cfg.Timezone != "test_timezone" {
 			t.Errorf("expected Timezone to be 'test_timezone', got '%s'", cfg.Timezone)
 		}
+		if cfg.NtfyURL != "https://ntfy.sh" {
+			t.Errorf("expected NtfyURL to default to 'https://ntfy.sh', got '%s'", cfg.NtfyURL)
+		}
 	})
 
 	t.Run("Missing environment variable", func(t *testing.T) {
@@ -66,3 +69,39 @@ func TestNewConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestLocations(t *testing.T) {
+	t.Run("Single location", func(t *testing.T) {
+		c := &Config{Location: "Madrid", Timezone: "Europe/Madrid"}
+
+		locations, err := c.Locations()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(locations) != 1 || locations[0].Name != "Madrid" || locations[0].Timezone != "Europe/Madrid" {
+			t.Errorf("unexpected locations %+v", locations)
+		}
+	})
+
+	t.Run("Timezone from the forecast", func(t *testing.T) {
+		c := &Config{Location: "Madrid"}
+
+		locations, err := c.Locations()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(locations) != 1 || locations[0].Timezone != "" {
+			t.Errorf("unexpected locations %+v", locations)
+		}
+	})
+
+	t.Run("No location", func(t *testing.T) {
+		c := &Config{Timezone: "Europe/Madrid"}
+
+		if _, err := c.Locations(); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
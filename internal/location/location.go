@@ -0,0 +1,303 @@
+package location
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/schedule"
+)
+
+// Location is a place to watch. Name identifies it in the notification
+// history, Query is what the weather provider is asked for.
+type Location struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	// Timezone defaults to the one the forecast reports for the location.
+	Timezone string `json:"timezone,omitempty"`
+	// Lat and Lon, when both set, locate the place exactly and replace Query.
+	Lat *float64 `json:"lat,omitempty"`
+	Lon *float64 `json:"lon,omitempty"`
+	// PostalCode replaces Query. WeatherAPI knows US, UK and Canadian
+	// postal codes, others need Geocode.
+	PostalCode string `json:"postal_code,omitempty"`
+	// Geocode resolves Query to coordinates once, narrowed down by Region
+	// and Country when the name is ambiguous, and caches the place.
+	Geocode bool   `json:"geocode,omitempty"`
+	Region  string `json:"region,omitempty"`
+	Country string `json:"country,omitempty"`
+	// Thresholds override the values in weather_config for this location.
+	Thresholds map[string]int `json:"thresholds,omitempty"`
+	// Topics are the ntfy topics notified for this location. Empty means
+	// the default topic.
+	Topics []string `json:"topics,omitempty"`
+	// SubscribersOnly skips the location's topics and notifies only the
+	// users subscribed to it.
+	SubscribersOnly bool `json:"subscribers_only,omitempty"`
+	// QuietHours are evaluated in the location's timezone.
+	QuietHours []QuietHours `json:"quiet_hours,omitempty"`
+	// Commutes are alerted ahead of time when rain is forecast inside them.
+	Commutes []Commute `json:"commutes,omitempty"`
+	// DisableNextHour turns off the next-hour alerts, leaving commute alerts
+	// and digests.
+	DisableNextHour bool `json:"disable_next_hour,omitempty"`
+	// DigestAt is the local time (HH:MM) of the daily digest. Empty means none.
+	DigestAt string `json:"digest_at,omitempty"`
+	// Outlook is the multi-day rain outlook. Nil means none.
+	Outlook *Outlook `json:"outlook,omitempty"`
+	// AirQuality requests the air quality forecast and alerts when it is poor.
+	AirQuality bool `json:"air_quality,omitempty"`
+}
+
+const (
+	MinOutlookDays = 3
+	MaxOutlookDays = 7
+)
+
+// Outlook is a weekly report of the coming days' rain, sent on the first run
+// at or after At on the given days, Sunday at 18:00 by default.
+type Outlook struct {
+	Days int           `json:"days"`
+	On   schedule.Days `json:"on,omitempty"`
+	At   string        `json:"at,omitempty"`
+}
+
+// Due reports whether the outlook should go out at t.
+func (o Outlook) Due(t time.Time) bool {
+	on := o.On
+	if on == 0 {
+		on = 1 << time.Sunday
+	}
+	at, err := schedule.MinuteOfDay(cmp.Or(o.At, "18:00"))
+	if err != nil {
+		return false
+	}
+	return on.Has(t.Weekday()) && t.Hour()*60+t.Minute() >= at
+}
+
+// Validate checks the number of days and the time.
+func (o Outlook) Validate() error {
+	if o.Days < MinOutlookDays || o.Days > MaxOutlookDays {
+		return fmt.Errorf("outlook days must be between %d and %d", MinOutlookDays, MaxOutlookDays)
+	}
+	if o.At != "" {
+		if _, err := schedule.MinuteOfDay(o.At); err != nil {
+			return fmt.Errorf("outlook at: %w", err)
+		}
+	}
+	return nil
+}
+
+// ParseOutlook decodes an outlook, as stored for subscriptions. An empty
+// string means none.
+func ParseOutlook(raw string) (*Outlook, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var o Outlook
+	if err := json.Unmarshal([]byte(raw), &o); err != nil {
+		return nil, fmt.Errorf("decoding outlook: %w", err)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// DefaultCommuteLead is how long before a commute it is alerted by default.
+const DefaultCommuteLead = time.Hour
+
+// Commute is a recurring window, e.g. weekdays 08:15-08:45.
+type Commute struct {
+	Name string `json:"name"`
+	schedule.Window
+	// Lead is how long before the commute starts to alert.
+	Lead schedule.Duration `json:"lead,omitempty"`
+}
+
+// LeadTime returns Lead, or DefaultCommuteLead when unset.
+func (c Commute) LeadTime() time.Duration {
+	if c.Lead <= 0 {
+		return DefaultCommuteLead
+	}
+	return time.Duration(c.Lead)
+}
+
+// ParseCommutes decodes a JSON array of commutes, as stored for
+// subscriptions. An empty string means none.
+func ParseCommutes(raw string) ([]Commute, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var commutes []Commute
+	if err := json.Unmarshal([]byte(raw), &commutes); err != nil {
+		return nil, fmt.Errorf("decoding commutes: %w", err)
+	}
+	return commutes, validateCommutes(commutes)
+}
+
+func validateCommutes(commutes []Commute) error {
+	for i, c := range commutes {
+		if c.Name == "" {
+			return fmt.Errorf("commute %d: name is required", i)
+		}
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("commute %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// QuietHours is a do-not-disturb window. With Hold, alerts raised inside
+// the window are kept and summarised once it ends instead of being dropped.
+type QuietHours struct {
+	schedule.Window
+	Hold bool `json:"hold,omitempty"`
+}
+
+// ParseQuietHours decodes a JSON array of quiet hours, as stored for
+// subscriptions. An empty string means none.
+func ParseQuietHours(raw string) ([]QuietHours, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var rules []QuietHours
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("decoding quiet hours: %w", err)
+	}
+	return rules, validateQuietHours(rules)
+}
+
+func validateQuietHours(rules []QuietHours) error {
+	for i, q := range rules {
+		if err := q.Validate(); err != nil {
+			return fmt.Errorf("quiet hours %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ActiveQuietHours returns the first rule containing t, if any.
+func ActiveQuietHours(rules []QuietHours, t time.Time) (QuietHours, bool) {
+	for _, q := range rules {
+		if q.Contains(t) {
+			return q, true
+		}
+	}
+	return QuietHours{}, false
+}
+
+// LoadFile reads a JSON array of locations.
+func LoadFile(path string) ([]Location, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading locations file: %w", err)
+	}
+
+	var locations []Location
+	if err := json.Unmarshal(content, &locations); err != nil {
+		return nil, fmt.Errorf("decoding locations file: %w", err)
+	}
+
+	for i := range locations {
+		if err := locations[i].validate(); err != nil {
+			return nil, fmt.Errorf("location %d: %w", i, err)
+		}
+	}
+
+	return locations, validateUnique(locations)
+}
+
+func (l *Location) validate() error {
+	if l.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if err := l.validatePlace(); err != nil {
+		return fmt.Errorf("%s: %w", l.Name, err)
+	}
+	if _, err := time.LoadLocation(l.Timezone); err != nil {
+		return fmt.Errorf("%s: invalid timezone: %w", l.Name, err)
+	}
+	if err := validateQuietHours(l.QuietHours); err != nil {
+		return fmt.Errorf("%s: %w", l.Name, err)
+	}
+	if err := validateCommutes(l.Commutes); err != nil {
+		return fmt.Errorf("%s: %w", l.Name, err)
+	}
+	if l.DigestAt != "" {
+		if _, err := schedule.MinuteOfDay(l.DigestAt); err != nil {
+			return fmt.Errorf("%s: digest_at: %w", l.Name, err)
+		}
+	}
+	if l.Outlook != nil {
+		if err := l.Outlook.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", l.Name, err)
+		}
+	}
+	return nil
+}
+
+func (l *Location) validatePlace() error {
+	if (l.Lat == nil) != (l.Lon == nil) {
+		return fmt.Errorf("lat and lon must be set together")
+	}
+	lat, lon, ok := l.Coordinates()
+	switch {
+	case ok && (l.Query != "" || l.PostalCode != ""):
+		return fmt.Errorf("lat and lon cannot be combined with query or postal_code")
+	case ok && l.Geocode:
+		return fmt.Errorf("lat and lon need no geocoding")
+	case ok && (lat < -90 || lat > 90 || lon < -180 || lon > 180):
+		return fmt.Errorf("invalid coordinates %g,%g", lat, lon)
+	case ok:
+		l.Query = FormatCoordinates(lat, lon)
+	case l.PostalCode != "" && l.Query != "":
+		return fmt.Errorf("postal_code cannot be combined with query")
+	case l.PostalCode != "":
+		l.Query = l.PostalCode
+	case l.Query == "":
+		l.Query = l.Name
+	}
+	return nil
+}
+
+// Coordinates returns the configured latitude and longitude, if any.
+func (l Location) Coordinates() (lat, lon float64, ok bool) {
+	if l.Lat == nil || l.Lon == nil {
+		return 0, 0, false
+	}
+	return *l.Lat, *l.Lon, true
+}
+
+// FormatCoordinates returns coordinates as a "lat,lon" query.
+func FormatCoordinates(lat, lon float64) string {
+	return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lon, 'f', -1, 64)
+}
+
+func validateUnique(locations []Location) error {
+	seen := make(map[string]bool)
+	for _, l := range locations {
+		if seen[l.Name] {
+			return fmt.Errorf("duplicate location %q", l.Name)
+		}
+		seen[l.Name] = true
+	}
+	return nil
+}
+
+// ThresholdsFrom returns the defaults overlaid with the location's overrides.
+func (l Location) ThresholdsFrom(defaults map[string]int) map[string]int {
+	merged := maps.Clone(defaults)
+	if merged == nil {
+		merged = make(map[string]int)
+	}
+	maps.Copy(merged, l.Thresholds)
+	return merged
+}
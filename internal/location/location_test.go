@@ -0,0 +1,205 @@
+package location
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "locations.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("Successful loading", func(t *testing.T) {
+		path := writeFile(t, `[
+			{"name": "office", "query": "Madrid", "timezone": "Europe/Madrid", "topics": ["office-rain"]},
+			{"name": "Berlin", "timezone": "Europe/Berlin", "thresholds": {"drizzleThreshold": 60}}
+		]`)
+
+		locations, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(locations) != 2 {
+			t.Fatalf("expected 2 locations, got %d", len(locations))
+		}
+		if locations[1].Query != "Berlin" {
+			t.Errorf("expected query to default to the name, got '%s'", locations[1].Query)
+		}
+	})
+
+	t.Run("Optional timezone", func(t *testing.T) {
+		path := writeFile(t, `[{"name": "office"}]`)
+
+		locations, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if locations[0].Timezone != "" {
+			t.Errorf("expected no timezone, got '%s'", locations[0].Timezone)
+		}
+	})
+
+	t.Run("Invalid timezone", func(t *testing.T) {
+		path := writeFile(t, `[{"name": "office", "timezone": "Mars/Olympus"}]`)
+
+		if _, err := LoadFile(path); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("Coordinates and postal codes", func(t *testing.T) {
+		path := writeFile(t, `[
+			{"name": "office", "lat": 40.4168, "lon": -3.7038, "timezone": "Europe/Madrid"},
+			{"name": "home", "postal_code": "SW1A 1AA", "timezone": "Europe/London"},
+			{"name": "Springfield", "geocode": true, "region": "Illinois", "timezone": "America/Chicago"}
+		]`)
+
+		locations, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, expected := range []string{"40.4168,-3.7038", "SW1A 1AA", "Springfield"} {
+			if locations[i].Query != expected {
+				t.Errorf("expected query '%s', got '%s'", expected, locations[i].Query)
+			}
+		}
+		if lat, lon, ok := locations[0].Coordinates(); !ok || lat != 40.4168 || lon != -3.7038 {
+			t.Errorf("unexpected coordinates %g,%g", lat, lon)
+		}
+		if _, _, ok := locations[1].Coordinates(); ok {
+			t.Error("expected no coordinates")
+		}
+	})
+
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{"Latitude only", `[{"name": "office", "lat": 40.4, "timezone": "UTC"}]`},
+		{"Coordinates out of range", `[{"name": "office", "lat": 140.4, "lon": 3, "timezone": "UTC"}]`},
+		{"Coordinates and query", `[{"name": "office", "query": "Madrid", "lat": 40.4, "lon": -3.7, "timezone": "UTC"}]`},
+		{"Geocoded coordinates", `[{"name": "office", "geocode": true, "lat": 40.4, "lon": -3.7, "timezone": "UTC"}]`},
+		{"Postal code and query", `[{"name": "office", "query": "Madrid", "postal_code": "28001", "timezone": "UTC"}]`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadFile(writeFile(t, tt.content)); err == nil {
+				t.Error("expected an error, but got nil")
+			}
+		})
+	}
+
+	t.Run("Duplicate names", func(t *testing.T) {
+		path := writeFile(t, `[{"name": "office", "timezone": "UTC"}, {"name": "office", "timezone": "UTC"}]`)
+
+		if _, err := LoadFile(path); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
+
+func TestThresholdsFrom(t *testing.T) {
+	loc := Location{Thresholds: map[string]int{"drizzleThreshold": 60}}
+	defaults := map[string]int{"drizzleThreshold": 50, "rainBeforeThreshold": 70}
+
+	merged := loc.ThresholdsFrom(defaults)
+
+	if merged["drizzleThreshold"] != 60 || merged["rainBeforeThreshold"] != 70 {
+		t.Errorf("unexpected thresholds %v", merged)
+	}
+	if defaults["drizzleThreshold"] != 50 {
+		t.Error("defaults were modified")
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		rules, err := ParseQuietHours(`[{"days": "weekdays", "start": "22:00", "end": "07:00", "hold": true}]`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rules) != 1 || !rules[0].Hold || rules[0].Start != "22:00" {
+			t.Errorf("unexpected rules %+v", rules)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		rules, err := ParseQuietHours("")
+		if err != nil || rules != nil {
+			t.Errorf("expected no rules, got %v, %v", rules, err)
+		}
+	})
+
+	t.Run("Invalid in locations file", func(t *testing.T) {
+		path := writeFile(t, `[{"name": "home", "timezone": "UTC", "quiet_hours": [{"start": "22:00"}]}]`)
+
+		if _, err := LoadFile(path); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
+
+func TestCommutes(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		commutes, err := ParseCommutes(`[
+			{"name": "morning", "days": "mon-fri", "start": "08:15", "end": "08:45", "lead": "45m"},
+			{"name": "evening", "days": "mon-fri", "start": "17:30", "end": "18:15"}
+		]`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(commutes) != 2 {
+			t.Fatalf("expected 2 commutes, got %d", len(commutes))
+		}
+		if commutes[0].LeadTime() != 45*time.Minute || commutes[1].LeadTime() != DefaultCommuteLead {
+			t.Errorf("unexpected lead times %s, %s", commutes[0].LeadTime(), commutes[1].LeadTime())
+		}
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+		if _, err := ParseCommutes(`[{"start": "08:15", "end": "08:45"}]`); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
+
+func TestOutlook(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		o, err := ParseOutlook(`{"days": 5, "on": "sat", "at": "19:30"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if o.Days != 5 || o.At != "19:30" {
+			t.Errorf("unexpected outlook %+v", o)
+		}
+	})
+
+	t.Run("Too many days", func(t *testing.T) {
+		if _, err := ParseOutlook(`{"days": 10}`); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("Due on Sunday evenings by default", func(t *testing.T) {
+		o := Outlook{Days: 7}
+		// 2025-07-13 is a Sunday.
+		if !o.Due(time.Date(2025, 7, 13, 18, 0, 0, 0, time.UTC)) {
+			t.Error("expected due on Sunday 18:00")
+		}
+		if o.Due(time.Date(2025, 7, 13, 17, 0, 0, 0, time.UTC)) {
+			t.Error("expected not due on Sunday 17:00")
+		}
+		if o.Due(time.Date(2025, 7, 14, 18, 0, 0, 0, time.UTC)) {
+			t.Error("expected not due on Monday")
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package message
+
+// builtin holds the default template sets by locale tag and set name.
+var builtin = map[string]map[string][]string{
+	"en": {
+		SetFun: {
+			"ALERT! Rain in {{.Location}} on {{when .Hour.Time}}!\n{{mm .Hour.PrecipMM}}mm expected.\nChance: {{.Hour.ChanceOfRain}}%\nGrab your umbrella or face the splash!",
+			"SKY LEAK! {{.Location}}, {{when .Hour.Time}} — {{mm .Hour.PrecipMM}}mm incoming!\nWetness odds: {{.Hour.ChanceOfRain}}%",
+			"RAIN TIME!\n{{.Location}}, {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}}mm on the way.\nChance: {{.Hour.ChanceOfRain}}%\nRejoice or retreat!",
+			"UMBRELLA ALERT!\n{{.Location}} on {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}}mm forecasted.\nRain chance: {{.Hour.ChanceOfRain}}%",
+			"NOT A DRILL!\nRain in {{.Location}} on {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}}mm expected.\nChance: {{.Hour.ChanceOfRain}}%",
+			"☁️ WET MODE ACTIVATED ☁️\n{{.Location}}, {{when .Hour.Time}}\nRain: {{mm .Hour.PrecipMM}}mm\nChance: {{.Hour.ChanceOfRain}}%",
+			"MOISTURE INCOMING!\n{{.Location}}, {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}}mm with {{.Hour.ChanceOfRain}}% chance\nGet poncho-ready!",
+			"DRENCH MODE: ON 💦\n{{.Location}}, {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}}mm rain\n{{.Hour.ChanceOfRain}}% chance",
+			"DRYNESS ERROR!\n{{.Location}}, {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}}mm of sogginess\nOdds: {{.Hour.ChanceOfRain}}%",
+			"⚠️ RAIN WARNING ⚠️\n{{.Location}}, {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}}mm\nChance: {{.Hour.ChanceOfRain}}%\nStay dry or embrace the drip.",
+		},
+		SetSerious: {
+			"Rain expected in {{.Location}} at {{clock .Hour.Time}}: {{mm .Hour.PrecipMM}} mm, {{.Hour.ChanceOfRain}}% chance ({{severity .Severity}} severity).{{range .Windows}}\nRain likely {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, up to {{.MaxChance}}%.{{end}}",
+		},
+	},
+	"es": {
+		SetFun: {
+			"¡ALERTA! Lluvia en {{.Location}} el {{when .Hour.Time}}.\nSe esperan {{mm .Hour.PrecipMM}} mm.\nProbabilidad: {{.Hour.ChanceOfRain}} %\n¡Coge el paraguas o prepárate para mojarte!",
+			"¡EL CIELO GOTEA! {{.Location}}, {{when .Hour.Time}}: {{mm .Hour.PrecipMM}} mm en camino.\nProbabilidad de remojón: {{.Hour.ChanceOfRain}} %",
+			"¡NO ES UN SIMULACRO!\nLluvia en {{.Location}} el {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}} mm previstos.\nProbabilidad: {{.Hour.ChanceOfRain}} %",
+		},
+		SetSerious: {
+			"Se prevé lluvia en {{.Location}} a las {{clock .Hour.Time}}: {{mm .Hour.PrecipMM}} mm, {{.Hour.ChanceOfRain}} % de probabilidad (gravedad {{severity .Severity}}).{{range .Windows}}\nLluvia probable {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, hasta {{.MaxChance}} %.{{end}}",
+		},
+	},
+	"de": {
+		SetFun: {
+			"ACHTUNG! Regen in {{.Location}} am {{when .Hour.Time}}!\n{{mm .Hour.PrecipMM}} mm erwartet.\nWahrscheinlichkeit: {{.Hour.ChanceOfRain}} %\nSchirm einpacken oder nass werden!",
+			"DER HIMMEL LECKT! {{.Location}}, {{when .Hour.Time}} – {{mm .Hour.PrecipMM}} mm im Anmarsch!\nNässequote: {{.Hour.ChanceOfRain}} %",
+			"KEINE ÜBUNG!\nRegen in {{.Location}} am {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}} mm vorhergesagt.\nWahrscheinlichkeit: {{.Hour.ChanceOfRain}} %",
+		},
+		SetSerious: {
+			"Regen in {{.Location}} um {{clock .Hour.Time}} erwartet: {{mm .Hour.PrecipMM}} mm, {{.Hour.ChanceOfRain}} % Wahrscheinlichkeit (Schweregrad: {{severity .Severity}}).{{range .Windows}}\nRegen wahrscheinlich {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, bis zu {{.MaxChance}} %.{{end}}",
+		},
+	},
+	"fr": {
+		SetFun: {
+			"ALERTE ! Pluie à {{.Location}} {{when .Hour.Time}} !\n{{mm .Hour.PrecipMM}} mm attendus.\nProbabilité : {{.Hour.ChanceOfRain}} %\nPrenez votre parapluie ou gare aux éclaboussures !",
+			"LE CIEL FUIT ! {{.Location}}, {{when .Hour.Time}} — {{mm .Hour.PrecipMM}} mm en approche !\nRisque de trempette : {{.Hour.ChanceOfRain}} %",
+			"CE N'EST PAS UN EXERCICE !\nPluie à {{.Location}} {{when .Hour.Time}}\n{{mm .Hour.PrecipMM}} mm prévus.\nProbabilité : {{.Hour.ChanceOfRain}} %",
+		},
+		SetSerious: {
+			"Pluie prévue à {{.Location}} à {{clock .Hour.Time}} : {{mm .Hour.PrecipMM}} mm, probabilité de {{.Hour.ChanceOfRain}} % (gravité {{severity .Severity}}).{{range .Windows}}\nPluie probable {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, jusqu'à {{.MaxChance}} %.{{end}}",
+		},
+	},
+}
@@ -0,0 +1,56 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Catalog loads and caches templates per set and locale, so recipients
+// with different preferences can share one run.
+type Catalog struct {
+	DefaultSet    string
+	DefaultLocale string
+	File          string
+	Store         Store
+	// NewPicker creates the picker for each loaded set. Nil means random.
+	NewPicker func() (Picker, error)
+
+	mu        sync.Mutex
+	templates map[string]*Templates
+}
+
+// Get returns the templates for a set and locale, empty values fall back to
+// the catalog defaults.
+func (c *Catalog) Get(ctx context.Context, set, locale string) (*Templates, error) {
+	if set == "" {
+		set = c.DefaultSet
+	}
+	if locale == "" {
+		locale = c.DefaultLocale
+	}
+	key := set + "/" + locale
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.templates[key]; ok {
+		return t, nil
+	}
+
+	t, err := Load(ctx, set, locale, c.File, c.Store)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s templates: %w", key, err)
+	}
+	if c.NewPicker != nil {
+		if t.Picker, err = c.NewPicker(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.templates == nil {
+		c.templates = make(map[string]*Templates)
+	}
+	c.templates[key] = t
+	return t, nil
+}
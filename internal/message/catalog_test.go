@@ -0,0 +1,34 @@
+package message
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	c := &Catalog{DefaultSet: SetFun, DefaultLocale: "en"}
+
+	t.Run("Defaults", func(t *testing.T) {
+		templates, err := c.Get(context.Background(), "", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if templates.Locale.Tag != "en" || len(templates.keys) != len(builtin["en"][SetFun]) {
+			t.Errorf("expected the default en/fun templates")
+		}
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		first, _ := c.Get(context.Background(), SetSerious, "es")
+		second, _ := c.Get(context.Background(), SetSerious, "es")
+		if first != second {
+			t.Error("expected templates to be cached")
+		}
+	})
+
+	t.Run("Unknown set", func(t *testing.T) {
+		if _, err := c.Get(context.Background(), "nope", "en"); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package message
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestBuiltinGolden renders every built-in template in order and compares
+// the output with testdata/<locale>_<set>.golden.
+func TestBuiltinGolden(t *testing.T) {
+	for tag, sets := range builtin {
+		for set := range sets {
+			t.Run(tag+"_"+set, func(t *testing.T) {
+				templates, err := Load(context.Background(), set, tag, "", nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				templates.Picker = &RoundRobin{}
+
+				var out []string
+				for range templates.keys {
+					msg, key, err := templates.Render(context.Background(), Audience{}, testData())
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if err := templates.Sent(context.Background(), Audience{}, key); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					out = append(out, msg)
+				}
+				got := strings.Join(out, "\n---\n") + "\n"
+
+				golden := filepath.Join("testdata", tag+"_"+set+".golden")
+				if *update {
+					if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("reading golden file: %v", err)
+				}
+				if got != string(want) {
+					t.Errorf("output does not match %s, run with -update to regenerate\ngot:\n%s", golden, got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// Locale holds the strings and formatting rules for one language.
+type Locale struct {
+	Tag      string
+	Title    string
+	decimal  string
+	weekdays [7]string
+	months   [12]string
+	dayFmt   string // verbs: weekday, day of month, month name
+	// severities translates the alert severities: low, moderate and high.
+	severities map[string]string
+}
+
+var locales = map[string]*Locale{
+	"en": {
+		Tag:        "en",
+		Title:      "Rain Alert",
+		decimal:    ".",
+		weekdays:   [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		months:     [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		dayFmt:     "%[1]s %[2]d %[3]s",
+		severities: map[string]string{"low": "low", "moderate": "moderate", "high": "high"},
+	},
+	"es": {
+		Tag:        "es",
+		Title:      "Alerta de lluvia",
+		decimal:    ",",
+		weekdays:   [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		months:     [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		dayFmt:     "%[1]s %[2]d de %[3]s",
+		severities: map[string]string{"low": "baja", "moderate": "moderada", "high": "alta"},
+	},
+	"de": {
+		Tag:        "de",
+		Title:      "Regenwarnung",
+		decimal:    ",",
+		weekdays:   [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		months:     [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		dayFmt:     "%[1]s, %[2]d. %[3]s",
+		severities: map[string]string{"low": "niedrig", "moderate": "mäßig", "high": "hoch"},
+	},
+	"fr": {
+		Tag:        "fr",
+		Title:      "Alerte pluie",
+		decimal:    ",",
+		weekdays:   [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		months:     [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		dayFmt:     "%[1]s %[2]d %[3]s",
+		severities: map[string]string{"low": "faible", "moderate": "modérée", "high": "élevée"},
+	},
+}
+
+// ErrUnsupportedLocale is returned for a locale without strings.
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
+// LookupLocale returns the locale for a tag such as "de", "de-DE" or "de_DE".
+func LookupLocale(tag string) (*Locale, error) {
+	lang := strings.ToLower(tag)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	l, ok := locales[lang]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedLocale, tag)
+	}
+	return l, nil
+}
+
+// Number formats v with the given number of decimals and the locale's
+// decimal separator.
+func (l *Locale) Number(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	return strings.Replace(s, ".", l.decimal, 1)
+}
+
+// Day formats the date part of a forecast time or date, e.g. "Thursday 10 July".
+func (l *Locale) Day(forecastTime string) string {
+	t, err := time.Parse(weather.TimeLayout, forecastTime)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, forecastTime); err != nil {
+			return forecastTime
+		}
+	}
+	return fmt.Sprintf(l.dayFmt, l.weekdays[t.Weekday()], t.Day(), l.months[t.Month()-1])
+}
+
+// Severity translates an alert severity such as "moderate", returning it
+// unchanged when unknown.
+func (l *Locale) Severity(severity string) string {
+	if s, ok := l.severities[severity]; ok {
+		return s
+	}
+	return severity
+}
+
+// When formats a forecast time as its day followed by the clock time.
+func (l *Locale) When(forecastTime string) string {
+	if _, err := time.Parse(weather.TimeLayout, forecastTime); err != nil {
+		return forecastTime
+	}
+	return l.Day(forecastTime) + ", " + clock(forecastTime)
+}
@@ -0,0 +1,31 @@
+package message
+
+import "testing"
+
+func TestLocaleFormatting(t *testing.T) {
+	tests := []struct {
+		tag    string
+		number string
+		when   string
+	}{
+		{"en", "1.50", "Thursday 10 July, 14:00"},
+		{"es_ES", "1,50", "jueves 10 de julio, 14:00"},
+		{"de", "1,50", "Donnerstag, 10. Juli, 14:00"},
+		{"fr-FR", "1,50", "jeudi 10 juillet, 14:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			l, err := LookupLocale(tt.tag)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := l.Number(1.5, 2); got != tt.number {
+				t.Errorf("expected number '%s', got '%s'", tt.number, got)
+			}
+			if got := l.When("2025-07-10 14:00"); got != tt.when {
+				t.Errorf("expected time '%s', got '%s'", tt.when, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+const (
+	SetFun     = "fun"
+	SetSerious = "serious"
+)
+
+// ErrUnknownSet is returned for a set with neither built-in nor stored
+// templates.
+var ErrUnknownSet = errors.New("unknown message set")
+
+// Data is what message templates are executed against.
+type Data struct {
+	Name     string // configured location name, e.g. "office"
+	Location string // location name reported by the provider
+	Hour     weather.Hour
+	Severity string
+	// Precipitation is the type of precipitation, e.g. "rain" or "snow".
+	Precipitation string
+	Windows       []weather.Window
+	Forecast      *weather.WeatherResponse
+}
+
+// Store loads user-defined templates for a set in a locale.
+type Store interface {
+	GetMessageTemplates(ctx context.Context, set, locale string) ([]string, error)
+}
+
+type Templates struct {
+	Locale *Locale
+	// Picker chooses the template to render. Defaults to a random picker.
+	Picker Picker
+
+	templates []*template.Template
+	keys      []string
+}
+
+func funcs(l *Locale) template.FuncMap {
+	return template.FuncMap{
+		"mm":       func(v float64) string { return l.Number(v, 2) },
+		"cm":       func(v float64) string { return l.Number(v, 1) },
+		"temp":     func(v float64) string { return l.Number(v, 1) },
+		"kph":      func(v float64) string { return l.Number(v, 0) },
+		"num":      func(v float64) string { return l.Number(v, 1) },
+		"clock":    clock,
+		"day":      l.Day,
+		"when":     l.When,
+		"severity": l.Severity,
+	}
+}
+
+// clock returns the HH:MM part of a forecast time such as "2025-07-10 14:00".
+func clock(t string) string {
+	if i := strings.LastIndexByte(t, ' '); i >= 0 {
+		return t[i+1:]
+	}
+	return t
+}
+
+// Parse compiles the given template bodies, formatting with the locale.
+func Parse(locale *Locale, bodies []string) (*Templates, error) {
+	if len(bodies) == 0 {
+		return nil, fmt.Errorf("no message templates")
+	}
+
+	t := &Templates{Locale: locale}
+	for i, body := range bodies {
+		tmpl, err := template.New(fmt.Sprintf("message-%d", i)).Funcs(funcs(locale)).Option("missingkey=error").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %d: %w", i, err)
+		}
+		t.templates = append(t.templates, tmpl)
+		t.keys = append(t.keys, templateKey(body))
+	}
+	return t, nil
+}
+
+// Load resolves the templates for a set and locale. A templates file takes
+// precedence, then templates stored for the set, then the built-in set.
+func Load(ctx context.Context, set, localeTag, file string, store Store) (*Templates, error) {
+	locale, err := LookupLocale(localeTag)
+	if err != nil {
+		return nil, err
+	}
+
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading templates file: %w", err)
+		}
+		return Parse(locale, splitTemplates(string(content)))
+	}
+
+	if store != nil {
+		bodies, err := store.GetMessageTemplates(ctx, set, locale.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("loading templates: %w", err)
+		}
+		if len(bodies) > 0 {
+			return Parse(locale, bodies)
+		}
+	}
+
+	bodies, ok := builtin[locale.Tag][set]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSet, set)
+	}
+	return Parse(locale, bodies)
+}
+
+// templateKey identifies a template by its content, so history survives
+// reordering of the templates.
+func templateKey(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:8])
+}
+
+// splitTemplates splits a templates file on lines containing only "---".
+func splitTemplates(content string) []string {
+	var bodies []string
+	for _, part := range strings.Split(content, "\n---\n") {
+		if part = strings.TrimSpace(part); part != "" {
+			bodies = append(bodies, part)
+		}
+	}
+	return bodies
+}
+
+// Render executes the template the picker chooses for the audience. It
+// returns the key of the template, to pass to Sent once the message was
+// delivered.
+func (t *Templates) Render(ctx context.Context, audience Audience, data Data) (msg, key string, err error) {
+	i, err := t.picker().Pick(ctx, audience, t.keys)
+	if err != nil {
+		return "", "", fmt.Errorf("picking template: %w", err)
+	}
+	tmpl := t.templates[i]
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("executing template %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), t.keys[i], nil
+}
+
+// Sent tells the picker that the message rendered from the template with key
+// was delivered to the audience.
+func (t *Templates) Sent(ctx context.Context, audience Audience, key string) error {
+	return t.picker().Sent(ctx, audience, key)
+}
+
+func (t *Templates) picker() Picker {
+	if t.Picker == nil {
+		return NewRandomPicker(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return t.Picker
+}
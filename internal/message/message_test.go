@@ -0,0 +1,145 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+type mockStore struct {
+	templates map[string][]string
+	err       error
+}
+
+func (m *mockStore) GetMessageTemplates(ctx context.Context, set, locale string) ([]string, error) {
+	return m.templates[set+"/"+locale], m.err
+}
+
+func testData() Data {
+	return Data{
+		Location: "Madrid",
+		Hour:     weather.Hour{Time: "2025-07-10 14:00", PrecipMM: 1.5, ChanceOfRain: 80},
+		Severity: "moderate",
+		Windows: []weather.Window{
+			{Start: "2025-07-10 14:00", End: "2025-07-10 16:00", MaxChance: 90, TotalMM: 4.2},
+		},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Built-in serious set", func(t *testing.T) {
+		templates, err := Load(context.Background(), SetSerious, "en", "", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		msg, _, err := templates.Render(context.Background(), Audience{}, testData())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Rain expected in Madrid at 14:00: 1.50 mm, 80% chance (moderate severity).\nRain likely 14:00–16:00, 4.20 mm, up to 90%."
+		if msg != expected {
+			t.Errorf("expected '%s', got '%s'", expected, msg)
+		}
+	})
+
+	t.Run("Every built-in template renders", func(t *testing.T) {
+		for tag, sets := range builtin {
+			locale, err := LookupLocale(tag)
+			if err != nil {
+				t.Fatalf("locale %s: unexpected error: %v", tag, err)
+			}
+			for set, bodies := range sets {
+				for _, body := range bodies {
+					templates, err := Parse(locale, []string{body})
+					if err != nil {
+						t.Fatalf("%s/%s: unexpected error: %v", tag, set, err)
+					}
+					if _, _, err := templates.Render(context.Background(), Audience{}, testData()); err != nil {
+						t.Errorf("%s/%s: unexpected error: %v", tag, set, err)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Store templates override built-in", func(t *testing.T) {
+		store := &mockStore{templates: map[string][]string{"work/en": {"Rain at {{clock .Hour.Time}} in {{.Location}}"}}}
+
+		templates, err := Load(context.Background(), "work", "en", "", store)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		msg, _, _ := templates.Render(context.Background(), Audience{}, testData())
+		if msg != "Rain at 14:00 in Madrid" {
+			t.Errorf("unexpected message '%s'", msg)
+		}
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		_, err := Load(context.Background(), SetFun, "en", "", &mockStore{err: errors.New("db down")})
+		if err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("Templates file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "templates.txt")
+		content := "First {{.Location}}\n---\nSecond {{.Location}}\n"
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		templates, err := Load(context.Background(), SetFun, "en", file, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		msg, _, _ := templates.Render(context.Background(), Audience{}, testData())
+		if !strings.HasSuffix(msg, "Madrid") {
+			t.Errorf("unexpected message '%s'", msg)
+		}
+	})
+
+	t.Run("Localized serious set", func(t *testing.T) {
+		templates, err := Load(context.Background(), SetSerious, "de-DE", "", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		msg, _, _ := templates.Render(context.Background(), Audience{}, testData())
+		expected := "Regen in Madrid um 14:00 erwartet: 1,50 mm, 80 % Wahrscheinlichkeit (Schweregrad: mäßig).\nRegen wahrscheinlich 14:00–16:00, 4,20 mm, bis zu 90 %."
+		if msg != expected {
+			t.Errorf("expected '%s', got '%s'", expected, msg)
+		}
+		if templates.Locale.Title != "Regenwarnung" {
+			t.Errorf("expected German title, got '%s'", templates.Locale.Title)
+		}
+	})
+
+	t.Run("Unsupported locale", func(t *testing.T) {
+		if _, err := Load(context.Background(), SetFun, "pt", "", nil); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("Unknown set", func(t *testing.T) {
+		if _, err := Load(context.Background(), "nope", "en", "", nil); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("Invalid template", func(t *testing.T) {
+		locale, _ := LookupLocale("en")
+		if _, err := Parse(locale, []string{"{{.Location"}); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
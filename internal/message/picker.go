@@ -0,0 +1,166 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+)
+
+const (
+	StrategyRandom     = "random"
+	StrategyRoundRobin = "round-robin"
+	StrategyNoRepeat   = "no-repeat"
+)
+
+// Audience is who messages are picked for: a location and one of its
+// subscriptions, 0 being the location's own topics. Each has its own message
+// history.
+type Audience struct {
+	Location       string
+	SubscriptionID int64
+}
+
+// Picker chooses which template to render next for an audience. keys
+// identify the candidate templates and are stable across runs. Sent is told
+// of the template once its message was delivered, so only messages actually
+// received count.
+type Picker interface {
+	Pick(ctx context.Context, audience Audience, keys []string) (int, error)
+	Sent(ctx context.Context, audience Audience, key string) error
+}
+
+// History records which templates were sent to each audience, so selection
+// can span runs.
+type History interface {
+	RecentMessages(ctx context.Context, location string, subscriptionID int64, limit int) ([]string, error)
+	RecordMessage(ctx context.Context, location string, subscriptionID int64, key string) error
+}
+
+// RandomPicker picks uniformly using its random source.
+type RandomPicker struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func NewRandomPicker(src rand.Source) *RandomPicker {
+	return &RandomPicker{rand: rand.New(src)}
+}
+
+func (p *RandomPicker) Pick(ctx context.Context, audience Audience, keys []string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rand.IntN(len(keys)), nil
+}
+
+func (p *RandomPicker) Sent(ctx context.Context, audience Audience, key string) error {
+	return nil
+}
+
+// RoundRobin cycles through the templates in order. With a History it
+// continues after the last template sent by a previous run.
+type RoundRobin struct {
+	History History
+
+	mu   sync.Mutex
+	last map[Audience]string
+}
+
+func (p *RoundRobin) Pick(ctx context.Context, audience Audience, keys []string) (int, error) {
+	p.mu.Lock()
+	last, ok := p.last[audience]
+	p.mu.Unlock()
+
+	if !ok && p.History != nil {
+		recent, err := p.History.RecentMessages(ctx, audience.Location, audience.SubscriptionID, 1)
+		if err != nil {
+			return 0, fmt.Errorf("reading message history: %w", err)
+		}
+		if len(recent) > 0 {
+			last = recent[0]
+		}
+	}
+
+	if i := slices.Index(keys, last); i >= 0 {
+		return (i + 1) % len(keys), nil
+	}
+	return 0, nil
+}
+
+func (p *RoundRobin) Sent(ctx context.Context, audience Audience, key string) error {
+	p.mu.Lock()
+	if p.last == nil {
+		p.last = make(map[Audience]string)
+	}
+	p.last[audience] = key
+	p.mu.Unlock()
+
+	if p.History == nil {
+		return nil
+	}
+	if err := p.History.RecordMessage(ctx, audience.Location, audience.SubscriptionID, key); err != nil {
+		return fmt.Errorf("recording message: %w", err)
+	}
+	return nil
+}
+
+// NoRepeat picks randomly among templates not sent to the audience within
+// its last Window messages. When every template was sent recently it falls
+// back to all.
+type NoRepeat struct {
+	History History
+	Window  int
+	Random  *RandomPicker
+}
+
+func (p *NoRepeat) Pick(ctx context.Context, audience Audience, keys []string) (int, error) {
+	recent, err := p.History.RecentMessages(ctx, audience.Location, audience.SubscriptionID, p.Window)
+	if err != nil {
+		return 0, fmt.Errorf("reading message history: %w", err)
+	}
+
+	var candidates []int
+	for i, key := range keys {
+		if !slices.Contains(recent, key) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range keys {
+			candidates = append(candidates, i)
+		}
+	}
+
+	c, err := p.Random.Pick(ctx, audience, make([]string, len(candidates)))
+	if err != nil {
+		return 0, err
+	}
+	return candidates[c], nil
+}
+
+func (p *NoRepeat) Sent(ctx context.Context, audience Audience, key string) error {
+	if err := p.History.RecordMessage(ctx, audience.Location, audience.SubscriptionID, key); err != nil {
+		return fmt.Errorf("recording message: %w", err)
+	}
+	return nil
+}
+
+// NewPicker builds the picker for a strategy. History is required for
+// no-repeat and optional for round-robin.
+func NewPicker(strategy string, window int, history History) (Picker, error) {
+	random := NewRandomPicker(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	switch strategy {
+	case StrategyRandom, "":
+		return random, nil
+	case StrategyRoundRobin:
+		return &RoundRobin{History: history}, nil
+	case StrategyNoRepeat:
+		if history == nil {
+			return nil, fmt.Errorf("%s strategy needs a message history", strategy)
+		}
+		return &NoRepeat{History: history, Window: window, Random: random}, nil
+	default:
+		return nil, fmt.Errorf("unknown message strategy %q", strategy)
+	}
+}
@@ -0,0 +1,146 @@
+package message
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+type mockHistory struct {
+	sent map[Audience][]string
+}
+
+func (m *mockHistory) RecentMessages(ctx context.Context, location string, subscriptionID int64, limit int) ([]string, error) {
+	sent := m.sent[Audience{location, subscriptionID}]
+	var recent []string
+	for i := len(sent) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, sent[i])
+	}
+	return recent, nil
+}
+
+func (m *mockHistory) RecordMessage(ctx context.Context, location string, subscriptionID int64, key string) error {
+	if m.sent == nil {
+		m.sent = make(map[Audience][]string)
+	}
+	audience := Audience{location, subscriptionID}
+	m.sent[audience] = append(m.sent[audience], key)
+	return nil
+}
+
+func TestRandomPicker(t *testing.T) {
+	keys := []string{"a", "b", "c", "d"}
+	first := NewRandomPicker(rand.NewPCG(1, 2))
+	second := NewRandomPicker(rand.NewPCG(1, 2))
+
+	for range 10 {
+		i, _ := first.Pick(context.Background(), Audience{}, keys)
+		j, _ := second.Pick(context.Background(), Audience{}, keys)
+		if i != j {
+			t.Fatalf("expected identical sequences for the same seed, got %d and %d", i, j)
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	keys := []string{"a", "b", "c"}
+	home := Audience{Location: "home"}
+
+	t.Run("In memory", func(t *testing.T) {
+		p := &RoundRobin{}
+		var got []int
+		for range 4 {
+			i, _ := p.Pick(context.Background(), home, keys)
+			p.Sent(context.Background(), home, keys[i])
+			got = append(got, i)
+		}
+		if !slices.Equal(got, []int{0, 1, 2, 0}) {
+			t.Errorf("unexpected sequence %v", got)
+		}
+	})
+
+	t.Run("Unsent picks do not advance", func(t *testing.T) {
+		p := &RoundRobin{}
+		p.Pick(context.Background(), home, keys)
+		if i, _ := p.Pick(context.Background(), home, keys); i != 0 {
+			t.Errorf("expected to pick index 0 again, got %d", i)
+		}
+	})
+
+	t.Run("Continues from history", func(t *testing.T) {
+		history := &mockHistory{sent: map[Audience][]string{home: {"b"}}}
+		p := &RoundRobin{History: history}
+
+		i, err := p.Pick(context.Background(), home, keys)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if i != 2 {
+			t.Errorf("expected to continue with index 2, got %d", i)
+		}
+		if len(history.sent[home]) != 1 {
+			t.Errorf("expected the pick not to be recorded before it is sent, got %v", history.sent)
+		}
+
+		if err := p.Sent(context.Background(), home, keys[i]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(history.sent[home], []string{"b", "c"}) {
+			t.Errorf("expected the sent message to be recorded, got %v", history.sent)
+		}
+	})
+
+	t.Run("Per audience", func(t *testing.T) {
+		history := &mockHistory{sent: map[Audience][]string{home: {"b"}}}
+		p := &RoundRobin{History: history}
+
+		if i, _ := p.Pick(context.Background(), Audience{Location: "home", SubscriptionID: 1}, keys); i != 0 {
+			t.Errorf("expected a subscriber to start at index 0, got %d", i)
+		}
+	})
+}
+
+func TestNoRepeat(t *testing.T) {
+	keys := []string{"a", "b", "c"}
+	home := Audience{Location: "home"}
+	history := &mockHistory{sent: map[Audience][]string{home: {"a", "c"}}}
+	p := &NoRepeat{History: history, Window: 2, Random: NewRandomPicker(rand.NewPCG(1, 2))}
+
+	i, err := p.Pick(context.Background(), home, keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys[i] != "b" {
+		t.Errorf("expected the only unsent template 'b', got '%s'", keys[i])
+	}
+
+	for range 10 {
+		i, _ := p.Pick(context.Background(), home, keys)
+		sent := history.sent[home]
+		if recent := sent[len(sent)-2:]; slices.Contains(recent, keys[i]) {
+			t.Fatalf("template '%s' repeated within window %v", keys[i], recent)
+		}
+		p.Sent(context.Background(), home, keys[i])
+	}
+
+	t.Run("Per audience", func(t *testing.T) {
+		picked := map[string]bool{}
+		for range 20 {
+			i, _ := p.Pick(context.Background(), Audience{Location: "office"}, keys)
+			picked[keys[i]] = true
+		}
+		if len(picked) != len(keys) {
+			t.Errorf("expected another audience to pick from every template, got %v", picked)
+		}
+	})
+}
+
+func TestNewPicker(t *testing.T) {
+	if _, err := NewPicker(StrategyNoRepeat, 3, nil); err == nil {
+		t.Error("expected an error for no-repeat without history, but got nil")
+	}
+	if _, err := NewPicker("shuffle", 3, nil); err == nil {
+		t.Error("expected an error for an unknown strategy, but got nil")
+	}
+}
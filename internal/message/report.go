@@ -0,0 +1,387 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+// Reports are fixed-format messages, unlike alerts they are not
+// user-configurable.
+const (
+	ReportHeld    = "held"
+	ReportCommute = "commute"
+	ReportDigest  = "digest"
+	ReportOutlook = "outlook"
+	ReportWarning = "warning"
+
+	// Alerts for precipitation other than rain, named after the type.
+	ReportSnow         = "snow"
+	ReportSleet        = "sleet"
+	ReportThunder      = "thunder"
+	ReportFreezingRain = "freezing-rain"
+
+	ReportWind     = "wind"
+	ReportRainWind = "rain-wind"
+
+	ReportAirQuality = "air-quality"
+	ReportUV         = "uv"
+	ReportHeat       = "heat"
+
+	ReportNowcast = "nowcast"
+)
+
+type report struct {
+	Title string
+	Body  string
+}
+
+// HeldData is rendered by the held report, sent once quiet hours end.
+type HeldData struct {
+	Name  string
+	Hours []weather.Hour
+}
+
+// CommuteData is rendered by the commute report, sent ahead of a commute
+// with rain forecast inside it.
+type CommuteData struct {
+	Name      string
+	Commute   string
+	Start     string // HH:MM
+	End       string // HH:MM
+	MaxChance int
+	TotalMM   float64
+}
+
+// DigestData is rendered by the daily digest.
+type DigestData struct {
+	Name string
+	Date string // YYYY-MM-DD
+	weather.Summary
+}
+
+// OutlookData is rendered by the multi-day outlook.
+type OutlookData struct {
+	Name string
+	Days []OutlookDay
+}
+
+type OutlookDay struct {
+	Date string // YYYY-MM-DD
+	weather.Summary
+}
+
+// WarningData is rendered by forwarded government weather alerts.
+type WarningData struct {
+	Name    string
+	Updated bool
+	weather.Alert
+}
+
+// HourData is rendered by the alerts about one forecast hour other than
+// rain: snow, sleet, thunder, freezing rain, wind, air quality, UV and heat.
+type HourData struct {
+	Name string
+	Hour weather.Hour
+}
+
+// NowcastData is rendered by the nowcast alert, sent ahead of rain. Times
+// are in minutes, rounded.
+type NowcastData struct {
+	Name string
+	// In is how long until the rain starts, 0 if it has.
+	In    int
+	Lasts int
+	// Longer is set when the rain may last longer than Lasts.
+	Longer  bool
+	TotalMM float64
+}
+
+var builtinReports = map[string]map[string]report{
+	"en": {
+		ReportNowcast: {
+			Title: "Rain soon",
+			Body:  "Rain {{if .In}}in ~{{.In}} minutes{{else}}starting now{{end}} in {{.Name}}, lasting {{if .Longer}}over {{else}}~{{end}}{{.Lasts}} minutes: {{mm .TotalMM}} mm.",
+		},
+		ReportUV: {
+			Title: "UV alert",
+			Body:  "High UV is forecast in {{.Name}} at {{clock .Hour.Time}}: index {{num .Hour.UV}}. Wear sunscreen, a hat and sunglasses, and seek shade around midday.",
+		},
+		ReportHeat: {
+			Title: "Heat alert",
+			Body:  "Heat stress is forecast in {{.Name}} at {{clock .Hour.Time}}: feels like {{temp .Hour.FeelsLikeC}} °C, heat index {{temp .Hour.HeatIndexC}} °C. Drink water and avoid exertion in the sun.",
+		},
+		ReportAirQuality: {
+			Title: "Air quality alert",
+			Body:  "Poor air quality is forecast in {{.Name}} at {{clock .Hour.Time}}{{with .Hour.AirQuality}}: {{if ge .USEPAIndex 6}}hazardous{{else if ge .USEPAIndex 5}}very unhealthy{{else if ge .USEPAIndex 4}}unhealthy{{else}}unhealthy for sensitive groups{{end}} (US EPA index {{.USEPAIndex}}), PM2.5 {{num .PM25}} µg/m³, PM10 {{num .PM10}} µg/m³, O₃ {{num .O3}} µg/m³{{end}}.",
+		},
+		ReportWind: {
+			Title: "Wind alert",
+			Body:  "Strong wind is forecast in {{.Name}} at {{clock .Hour.Time}}: {{kph .Hour.WindKPH}} km/h from the {{.Hour.WindDir}}, gusts up to {{kph .Hour.GustKPH}} km/h.",
+		},
+		ReportRainWind: {
+			Title: "Rain and strong gusts",
+			Body:  "Rain and strong gusts are forecast in {{.Name}} at {{clock .Hour.Time}}: {{.Hour.Chance}}%, {{mm .Hour.PrecipMM}} mm, gusts up to {{kph .Hour.GustKPH}} km/h from the {{.Hour.WindDir}}. An umbrella won't help, take a raincoat.",
+		},
+		ReportSnow: {
+			Title: "Snow alert",
+			Body:  "Snow is forecast in {{.Name}} at {{clock .Hour.Time}}: {{.Hour.ChanceOfSnow}}%{{if .Hour.SnowCM}}, {{cm .Hour.SnowCM}} cm{{end}}.",
+		},
+		ReportSleet: {
+			Title: "Sleet alert",
+			Body:  "Sleet is forecast in {{.Name}} at {{clock .Hour.Time}}: {{.Hour.Chance}}%, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportThunder: {
+			Title: "Thunderstorm alert",
+			Body:  "Thunderstorms are forecast in {{.Name}} at {{clock .Hour.Time}}: {{.Hour.ChanceOfRain}}%, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportFreezingRain: {
+			Title: "Freezing rain alert",
+			Body:  "Freezing rain is forecast in {{.Name}} at {{clock .Hour.Time}} ({{temp .Hour.TempC}} °C): expect icy roads and pavements.",
+		},
+		ReportWarning: {
+			Title: "Weather warning",
+			Body:  "{{if .Updated}}Updated: {{end}}{{.Headline}} in {{.Name}}{{if .Desc}}\n\n{{.Desc}}{{end}}{{if .Instruction}}\n\n{{.Instruction}}{{end}}",
+		},
+		ReportOutlook: {
+			Title: "Rain outlook",
+			Body:  "Rain outlook for {{.Name}}:{{range .Days}}\n{{day .Date}}: {{if .Windows}}{{mm .TotalMM}} mm, up to {{.MaxChance}}%{{range .Windows}}, wet {{clock .Start}}–{{clock .End}}{{end}}{{else}}dry{{end}}{{end}}",
+		},
+		ReportDigest: {
+			Title: "Daily rain digest",
+			Body:  "{{day .Date}} in {{.Name}}: {{if .Windows}}{{mm .TotalMM}} mm in total, up to {{.MaxChance}}%, heaviest at {{clock .Peak.Time}} ({{mm .Peak.PrecipMM}} mm).{{range .Windows}}\nRain likely {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, up to {{.MaxChance}}%.{{end}}{{else}}no rain expected.{{end}}",
+		},
+		ReportHeld: {
+			Title: "Rain alerts during quiet hours",
+			Body:  "While you were away, rain was forecast in {{.Name}}:{{range .Hours}}\n{{when .Time}}: {{mm .PrecipMM}} mm, {{.ChanceOfRain}}%{{end}}",
+		},
+		ReportCommute: {
+			Title: "Rain on your commute",
+			Body:  "Rain is forecast in {{.Name}} during your {{.Commute}} commute ({{.Start}}–{{.End}}): up to {{.MaxChance}}%, {{mm .TotalMM}} mm.",
+		},
+	},
+	"es": {
+		ReportNowcast: {
+			Title: "Lluvia inminente",
+			Body:  "Lluvia {{if .In}}en ~{{.In}} minutos{{else}}desde ahora{{end}} en {{.Name}}, durante {{if .Longer}}más de {{else}}~{{end}}{{.Lasts}} minutos: {{mm .TotalMM}} mm.",
+		},
+		ReportUV: {
+			Title: "Alerta de UV",
+			Body:  "Se prevé radiación UV alta en {{.Name}} a las {{clock .Hour.Time}}: índice {{num .Hour.UV}}. Usa protector solar, gorra y gafas de sol, y busca la sombra a mediodía.",
+		},
+		ReportHeat: {
+			Title: "Alerta de calor",
+			Body:  "Se prevé estrés térmico en {{.Name}} a las {{clock .Hour.Time}}: sensación de {{temp .Hour.FeelsLikeC}} °C, índice de calor {{temp .Hour.HeatIndexC}} °C. Bebe agua y evita esfuerzos al sol.",
+		},
+		ReportAirQuality: {
+			Title: "Alerta de calidad del aire",
+			Body:  "Se prevé mala calidad del aire en {{.Name}} a las {{clock .Hour.Time}}{{with .Hour.AirQuality}}: {{if ge .USEPAIndex 6}}peligrosa{{else if ge .USEPAIndex 5}}muy perjudicial{{else if ge .USEPAIndex 4}}perjudicial{{else}}perjudicial para grupos sensibles{{end}} (índice EPA {{.USEPAIndex}}), PM2,5 {{num .PM25}} µg/m³, PM10 {{num .PM10}} µg/m³, O₃ {{num .O3}} µg/m³{{end}}.",
+		},
+		ReportWind: {
+			Title: "Alerta de viento",
+			Body:  "Se prevé viento fuerte en {{.Name}} a las {{clock .Hour.Time}}: {{kph .Hour.WindKPH}} km/h del {{.Hour.WindDir}}, rachas de hasta {{kph .Hour.GustKPH}} km/h.",
+		},
+		ReportRainWind: {
+			Title: "Lluvia y rachas fuertes",
+			Body:  "Se prevén lluvia y rachas fuertes en {{.Name}} a las {{clock .Hour.Time}}: {{.Hour.Chance}} %, {{mm .Hour.PrecipMM}} mm, rachas de hasta {{kph .Hour.GustKPH}} km/h del {{.Hour.WindDir}}. El paraguas no servirá, mejor un chubasquero.",
+		},
+		ReportSnow: {
+			Title: "Alerta de nieve",
+			Body:  "Se prevé nieve en {{.Name}} a las {{clock .Hour.Time}}: {{.Hour.ChanceOfSnow}} %{{if .Hour.SnowCM}}, {{cm .Hour.SnowCM}} cm{{end}}.",
+		},
+		ReportSleet: {
+			Title: "Alerta de aguanieve",
+			Body:  "Se prevé aguanieve en {{.Name}} a las {{clock .Hour.Time}}: {{.Hour.Chance}} %, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportThunder: {
+			Title: "Alerta de tormenta",
+			Body:  "Se prevén tormentas en {{.Name}} a las {{clock .Hour.Time}}: {{.Hour.ChanceOfRain}} %, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportFreezingRain: {
+			Title: "Alerta de lluvia helada",
+			Body:  "Se prevé lluvia helada en {{.Name}} a las {{clock .Hour.Time}} ({{temp .Hour.TempC}} °C): cuidado con el hielo en calles y aceras.",
+		},
+		ReportWarning: {
+			Title: "Aviso meteorológico",
+			Body:  "{{if .Updated}}Actualizado: {{end}}{{.Headline}} en {{.Name}}{{if .Desc}}\n\n{{.Desc}}{{end}}{{if .Instruction}}\n\n{{.Instruction}}{{end}}",
+		},
+		ReportOutlook: {
+			Title: "Previsión de lluvia",
+			Body:  "Previsión de lluvia para {{.Name}}:{{range .Days}}\n{{day .Date}}: {{if .Windows}}{{mm .TotalMM}} mm, hasta {{.MaxChance}} %{{range .Windows}}, lluvia {{clock .Start}}–{{clock .End}}{{end}}{{else}}seco{{end}}{{end}}",
+		},
+		ReportDigest: {
+			Title: "Resumen diario de lluvia",
+			Body:  "{{day .Date}} en {{.Name}}: {{if .Windows}}{{mm .TotalMM}} mm en total, hasta {{.MaxChance}} %, máximo a las {{clock .Peak.Time}} ({{mm .Peak.PrecipMM}} mm).{{range .Windows}}\nLluvia probable {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, hasta {{.MaxChance}} %.{{end}}{{else}}no se espera lluvia.{{end}}",
+		},
+		ReportHeld: {
+			Title: "Alertas de lluvia en horas de silencio",
+			Body:  "Mientras no estabas, se previó lluvia en {{.Name}}:{{range .Hours}}\n{{when .Time}}: {{mm .PrecipMM}} mm, {{.ChanceOfRain}} %{{end}}",
+		},
+		ReportCommute: {
+			Title: "Lluvia en tu trayecto",
+			Body:  "Se prevé lluvia en {{.Name}} durante tu trayecto {{.Commute}} ({{.Start}}–{{.End}}): hasta {{.MaxChance}} %, {{mm .TotalMM}} mm.",
+		},
+	},
+	"de": {
+		ReportNowcast: {
+			Title: "Regen in Kürze",
+			Body:  "Regen {{if .In}}in ~{{.In}} Minuten{{else}}ab jetzt{{end}} in {{.Name}}, {{if .Longer}}über {{else}}~{{end}}{{.Lasts}} Minuten lang: {{mm .TotalMM}} mm.",
+		},
+		ReportUV: {
+			Title: "UV-Warnung",
+			Body:  "Hohe UV-Strahlung ist in {{.Name}} um {{clock .Hour.Time}} vorhergesagt: Index {{num .Hour.UV}}. Sonnencreme, Hut und Sonnenbrille nicht vergessen, mittags den Schatten suchen.",
+		},
+		ReportHeat: {
+			Title: "Hitzewarnung",
+			Body:  "Hitzebelastung ist in {{.Name}} um {{clock .Hour.Time}} vorhergesagt: gefühlt {{temp .Hour.FeelsLikeC}} °C, Hitzeindex {{temp .Hour.HeatIndexC}} °C. Viel trinken und Anstrengung in der Sonne vermeiden.",
+		},
+		ReportAirQuality: {
+			Title: "Luftqualitätswarnung",
+			Body:  "Schlechte Luftqualität ist in {{.Name}} um {{clock .Hour.Time}} vorhergesagt{{with .Hour.AirQuality}}: {{if ge .USEPAIndex 6}}gefährlich{{else if ge .USEPAIndex 5}}sehr ungesund{{else if ge .USEPAIndex 4}}ungesund{{else}}ungesund für empfindliche Gruppen{{end}} (US-EPA-Index {{.USEPAIndex}}), PM2,5 {{num .PM25}} µg/m³, PM10 {{num .PM10}} µg/m³, O₃ {{num .O3}} µg/m³{{end}}.",
+		},
+		ReportWind: {
+			Title: "Windwarnung",
+			Body:  "Starker Wind ist in {{.Name}} um {{clock .Hour.Time}} vorhergesagt: {{kph .Hour.WindKPH}} km/h aus {{.Hour.WindDir}}, Böen bis {{kph .Hour.GustKPH}} km/h.",
+		},
+		ReportRainWind: {
+			Title: "Regen und starke Böen",
+			Body:  "Regen und starke Böen sind in {{.Name}} um {{clock .Hour.Time}} vorhergesagt: {{.Hour.Chance}} %, {{mm .Hour.PrecipMM}} mm, Böen bis {{kph .Hour.GustKPH}} km/h aus {{.Hour.WindDir}}. Ein Schirm hilft nicht, besser eine Regenjacke.",
+		},
+		ReportSnow: {
+			Title: "Schneewarnung",
+			Body:  "Schnee ist in {{.Name}} um {{clock .Hour.Time}} vorhergesagt: {{.Hour.ChanceOfSnow}} %{{if .Hour.SnowCM}}, {{cm .Hour.SnowCM}} cm{{end}}.",
+		},
+		ReportSleet: {
+			Title: "Schneeregenwarnung",
+			Body:  "Schneeregen ist in {{.Name}} um {{clock .Hour.Time}} vorhergesagt: {{.Hour.Chance}} %, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportThunder: {
+			Title: "Gewitterwarnung",
+			Body:  "Gewitter sind in {{.Name}} um {{clock .Hour.Time}} vorhergesagt: {{.Hour.ChanceOfRain}} %, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportFreezingRain: {
+			Title: "Glatteiswarnung",
+			Body:  "Gefrierender Regen ist in {{.Name}} um {{clock .Hour.Time}} vorhergesagt ({{temp .Hour.TempC}} °C): Straßen und Gehwege können glatt sein.",
+		},
+		ReportWarning: {
+			Title: "Unwetterwarnung",
+			Body:  "{{if .Updated}}Aktualisiert: {{end}}{{.Headline}} für {{.Name}}{{if .Desc}}\n\n{{.Desc}}{{end}}{{if .Instruction}}\n\n{{.Instruction}}{{end}}",
+		},
+		ReportOutlook: {
+			Title: "Regenausblick",
+			Body:  "Regenausblick für {{.Name}}:{{range .Days}}\n{{day .Date}}: {{if .Windows}}{{mm .TotalMM}} mm, bis zu {{.MaxChance}} %{{range .Windows}}, nass {{clock .Start}}–{{clock .End}}{{end}}{{else}}trocken{{end}}{{end}}",
+		},
+		ReportDigest: {
+			Title: "Tägliche Regenübersicht",
+			Body:  "{{day .Date}} in {{.Name}}: {{if .Windows}}insgesamt {{mm .TotalMM}} mm, bis zu {{.MaxChance}} %, am stärksten um {{clock .Peak.Time}} ({{mm .Peak.PrecipMM}} mm).{{range .Windows}}\nRegen wahrscheinlich {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, bis zu {{.MaxChance}} %.{{end}}{{else}}kein Regen erwartet.{{end}}",
+		},
+		ReportHeld: {
+			Title: "Regenwarnungen während der Ruhezeit",
+			Body:  "Während der Ruhezeit war Regen in {{.Name}} vorhergesagt:{{range .Hours}}\n{{when .Time}}: {{mm .PrecipMM}} mm, {{.ChanceOfRain}} %{{end}}",
+		},
+		ReportCommute: {
+			Title: "Regen auf deinem Arbeitsweg",
+			Body:  "In {{.Name}} ist während deines Wegs {{.Commute}} ({{.Start}}–{{.End}}) Regen vorhergesagt: bis zu {{.MaxChance}} %, {{mm .TotalMM}} mm.",
+		},
+	},
+	"fr": {
+		ReportNowcast: {
+			Title: "Pluie imminente",
+			Body:  "Pluie {{if .In}}dans ~{{.In}} minutes{{else}}dès maintenant{{end}} à {{.Name}}, pendant {{if .Longer}}plus de {{else}}~{{end}}{{.Lasts}} minutes : {{mm .TotalMM}} mm.",
+		},
+		ReportUV: {
+			Title: "Alerte UV",
+			Body:  "Un indice UV élevé est prévu à {{.Name}} à {{clock .Hour.Time}} : indice {{num .Hour.UV}}. Crème solaire, chapeau et lunettes de soleil, et restez à l'ombre vers midi.",
+		},
+		ReportHeat: {
+			Title: "Alerte chaleur",
+			Body:  "Un stress thermique est prévu à {{.Name}} à {{clock .Hour.Time}} : ressenti {{temp .Hour.FeelsLikeC}} °C, indice de chaleur {{temp .Hour.HeatIndexC}} °C. Buvez de l'eau et évitez les efforts au soleil.",
+		},
+		ReportAirQuality: {
+			Title: "Alerte qualité de l'air",
+			Body:  "Mauvaise qualité de l'air prévue à {{.Name}} à {{clock .Hour.Time}}{{with .Hour.AirQuality}} : {{if ge .USEPAIndex 6}}dangereuse{{else if ge .USEPAIndex 5}}très mauvaise{{else if ge .USEPAIndex 4}}mauvaise{{else}}mauvaise pour les personnes sensibles{{end}} (indice EPA {{.USEPAIndex}}), PM2,5 {{num .PM25}} µg/m³, PM10 {{num .PM10}} µg/m³, O₃ {{num .O3}} µg/m³{{end}}.",
+		},
+		ReportWind: {
+			Title: "Alerte vent",
+			Body:  "Vent fort prévu à {{.Name}} à {{clock .Hour.Time}} : {{kph .Hour.WindKPH}} km/h de secteur {{.Hour.WindDir}}, rafales jusqu'à {{kph .Hour.GustKPH}} km/h.",
+		},
+		ReportRainWind: {
+			Title: "Pluie et fortes rafales",
+			Body:  "Pluie et fortes rafales prévues à {{.Name}} à {{clock .Hour.Time}} : {{.Hour.Chance}} %, {{mm .Hour.PrecipMM}} mm, rafales jusqu'à {{kph .Hour.GustKPH}} km/h de secteur {{.Hour.WindDir}}. Le parapluie ne servira à rien, prenez un imperméable.",
+		},
+		ReportSnow: {
+			Title: "Alerte neige",
+			Body:  "De la neige est prévue à {{.Name}} à {{clock .Hour.Time}} : {{.Hour.ChanceOfSnow}} %{{if .Hour.SnowCM}}, {{cm .Hour.SnowCM}} cm{{end}}.",
+		},
+		ReportSleet: {
+			Title: "Alerte neige fondue",
+			Body:  "De la neige fondue est prévue à {{.Name}} à {{clock .Hour.Time}} : {{.Hour.Chance}} %, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportThunder: {
+			Title: "Alerte orages",
+			Body:  "Des orages sont prévus à {{.Name}} à {{clock .Hour.Time}} : {{.Hour.ChanceOfRain}} %, {{mm .Hour.PrecipMM}} mm.",
+		},
+		ReportFreezingRain: {
+			Title: "Alerte pluie verglaçante",
+			Body:  "De la pluie verglaçante est prévue à {{.Name}} à {{clock .Hour.Time}} ({{temp .Hour.TempC}} °C) : attention au verglas sur les routes et trottoirs.",
+		},
+		ReportWarning: {
+			Title: "Alerte météo",
+			Body:  "{{if .Updated}}Mise à jour : {{end}}{{.Headline}} pour {{.Name}}{{if .Desc}}\n\n{{.Desc}}{{end}}{{if .Instruction}}\n\n{{.Instruction}}{{end}}",
+		},
+		ReportOutlook: {
+			Title: "Perspectives de pluie",
+			Body:  "Perspectives de pluie pour {{.Name}} :{{range .Days}}\n{{day .Date}} : {{if .Windows}}{{mm .TotalMM}} mm, jusqu'à {{.MaxChance}} %{{range .Windows}}, pluie {{clock .Start}}–{{clock .End}}{{end}}{{else}}sec{{end}}{{end}}",
+		},
+		ReportDigest: {
+			Title: "Résumé quotidien de la pluie",
+			Body:  "{{day .Date}} à {{.Name}} : {{if .Windows}}{{mm .TotalMM}} mm au total, jusqu'à {{.MaxChance}} %, maximum à {{clock .Peak.Time}} ({{mm .Peak.PrecipMM}} mm).{{range .Windows}}\nPluie probable {{clock .Start}}–{{clock .End}}, {{mm .TotalMM}} mm, jusqu'à {{.MaxChance}} %.{{end}}{{else}}pas de pluie prévue.{{end}}",
+		},
+		ReportHeld: {
+			Title: "Alertes pluie pendant les heures calmes",
+			Body:  "Pendant votre absence, de la pluie était prévue à {{.Name}} :{{range .Hours}}\n{{when .Time}} : {{mm .PrecipMM}} mm, {{.ChanceOfRain}} %{{end}}",
+		},
+		ReportCommute: {
+			Title: "Pluie sur votre trajet",
+			Body:  "De la pluie est prévue à {{.Name}} pendant votre trajet {{.Commute}} ({{.Start}}–{{.End}}) : jusqu'à {{.MaxChance}} %, {{mm .TotalMM}} mm.",
+		},
+	},
+}
+
+// Report renders a built-in report in the locale.
+func (l *Locale) Report(name string, data any) (title, body string, err error) {
+	r, ok := builtinReports[l.Tag][name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown report %q", name)
+	}
+
+	body, err = l.Execute(name, r.Body, data)
+	if err != nil {
+		return "", "", fmt.Errorf("%s report: %w", name, err)
+	}
+	return r.Title, body, nil
+}
+
+// Execute runs a one-off template, such as a report or an alert rule's
+// message, formatting with the locale.
+func (l *Locale) Execute(name, body string, data any) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs(l)).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("parsing: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// CheckTemplate reports whether a one-off template parses.
+func CheckTemplate(body string) error {
+	l, _ := LookupLocale("en")
+	_, err := template.New("check").Funcs(funcs(l)).Parse(body)
+	return err
+}
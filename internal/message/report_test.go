@@ -0,0 +1,212 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+func TestReport(t *testing.T) {
+	data := HeldData{
+		Name: "home",
+		Hours: []weather.Hour{
+			{Time: "2025-07-10 03:00", PrecipMM: 1.2, ChanceOfRain: 80},
+			{Time: "2025-07-10 04:00", PrecipMM: 0.4, ChanceOfRain: 65},
+		},
+	}
+
+	t.Run("Held summary", func(t *testing.T) {
+		l, _ := LookupLocale("en")
+
+		title, body, err := l.Report(ReportHeld, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if title != "Rain alerts during quiet hours" {
+			t.Errorf("unexpected title '%s'", title)
+		}
+		expected := "While you were away, rain was forecast in home:\nThursday 10 July, 03:00: 1.20 mm, 80%\nThursday 10 July, 04:00: 0.40 mm, 65%"
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+	})
+
+	t.Run("Commute", func(t *testing.T) {
+		l, _ := LookupLocale("de")
+
+		_, body, err := l.Report(ReportCommute, CommuteData{Name: "Berlin", Commute: "Rad", Start: "08:15", End: "08:45", MaxChance: 70, TotalMM: 0.8})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "In Berlin ist während deines Wegs Rad (08:15–08:45) Regen vorhergesagt: bis zu 70 %, 0,80 mm."
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+	})
+
+	t.Run("Digest", func(t *testing.T) {
+		l, _ := LookupLocale("en")
+
+		_, body, err := l.Report(ReportDigest, DigestData{Name: "home", Date: "2025-07-10", Summary: weather.Summarize(data.Hours, 50)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Thursday 10 July in home: 1.60 mm in total, up to 80%, heaviest at 03:00 (1.20 mm).\nRain likely 03:00–04:00, 1.60 mm, up to 80%."
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+
+		_, body, _ = l.Report(ReportDigest, DigestData{Name: "home", Date: "2025-07-10"})
+		if body != "Thursday 10 July in home: no rain expected." {
+			t.Errorf("unexpected dry digest '%s'", body)
+		}
+	})
+
+	t.Run("Outlook", func(t *testing.T) {
+		l, _ := LookupLocale("es")
+
+		_, body, err := l.Report(ReportOutlook, OutlookData{Name: "Madrid", Days: []OutlookDay{
+			{Date: "2025-07-14", Summary: weather.Summarize(nil, 50)},
+			{Date: "2025-07-15", Summary: weather.Summarize(data.Hours, 50)},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Previsión de lluvia para Madrid:\nlunes 14 de julio: seco\nmartes 15 de julio: 1,60 mm, hasta 80 %, lluvia 03:00–04:00"
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+	})
+
+	t.Run("Warning", func(t *testing.T) {
+		l, _ := LookupLocale("en")
+
+		alert := weather.Alert{Headline: "Orange thunderstorm warning", Desc: "Storms with hail."}
+		_, body, err := l.Report(ReportWarning, WarningData{Name: "office", Updated: true, Alert: alert})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Updated: Orange thunderstorm warning in office\n\nStorms with hail."
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+	})
+
+	t.Run("Snow", func(t *testing.T) {
+		l, _ := LookupLocale("de")
+
+		title, body, err := l.Report(ReportSnow, HourData{Name: "Berlin", Hour: weather.Hour{Time: "2025-01-10 08:00", ChanceOfSnow: 75, SnowCM: 2.5}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Schnee ist in Berlin um 08:00 vorhergesagt: 75 %, 2,5 cm."
+		if title != "Schneewarnung" || body != expected {
+			t.Errorf("expected '%s', got '%s: %s'", expected, title, body)
+		}
+	})
+
+	t.Run("Rain and wind", func(t *testing.T) {
+		l, _ := LookupLocale("en")
+
+		hour := weather.Hour{Time: "2025-10-10 18:00", ChanceOfRain: 80, PrecipMM: 1.5, GustKPH: 62.4, WindDir: "SW"}
+		_, body, err := l.Report(ReportRainWind, HourData{Name: "office", Hour: hour})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Rain and strong gusts are forecast in office at 18:00: 80%, 1.50 mm, gusts up to 62 km/h from the SW. An umbrella won't help, take a raincoat."
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+	})
+
+	t.Run("Air quality", func(t *testing.T) {
+		l, _ := LookupLocale("en")
+
+		hour := weather.Hour{Time: "2025-07-10 17:00", AirQuality: &weather.AirQuality{PM25: 56.3, PM10: 80, O3: 140.5, USEPAIndex: 5}}
+		_, body, err := l.Report(ReportAirQuality, HourData{Name: "school", Hour: hour})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Poor air quality is forecast in school at 17:00: very unhealthy (US EPA index 5), PM2.5 56.3 µg/m³, PM10 80.0 µg/m³, O₃ 140.5 µg/m³."
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+	})
+
+	t.Run("Heat", func(t *testing.T) {
+		l, _ := LookupLocale("fr")
+
+		hour := weather.Hour{Time: "2025-07-10 16:00", FeelsLikeC: 38.4, HeatIndexC: 40.2}
+		_, body, err := l.Report(ReportHeat, HourData{Name: "Paris", Hour: hour})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Un stress thermique est prévu à Paris à 16:00 : ressenti 38,4 °C, indice de chaleur 40,2 °C. Buvez de l'eau et évitez les efforts au soleil."
+		if body != expected {
+			t.Errorf("expected '%s', got '%s'", expected, body)
+		}
+	})
+
+	t.Run("Nowcast", func(t *testing.T) {
+		for _, tt := range []struct {
+			data     NowcastData
+			expected string
+		}{
+			{NowcastData{Name: "office", In: 20, Lasts: 40, TotalMM: 1.2}, "Rain in ~20 minutes in office, lasting ~40 minutes: 1.20 mm."},
+			{NowcastData{Name: "office", Lasts: 90, Longer: true, TotalMM: 3}, "Rain starting now in office, lasting over 90 minutes: 3.00 mm."},
+		} {
+			l, _ := LookupLocale("en")
+			_, body, err := l.Report(ReportNowcast, tt.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if body != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, body)
+			}
+		}
+	})
+
+	samples := map[string]any{
+		ReportNowcast:      NowcastData{Name: "home", In: 15, Lasts: 30},
+		ReportUV:           HourData{Name: "home", Hour: weather.Hour{Time: "2025-07-10 13:00", UV: 9}},
+		ReportHeat:         HourData{Name: "home", Hour: weather.Hour{Time: "2025-07-10 16:00", FeelsLikeC: 38}},
+		ReportAirQuality:   HourData{Name: "home", Hour: weather.Hour{Time: "2025-01-10 08:00", AirQuality: &weather.AirQuality{USEPAIndex: 4}}},
+		ReportWind:         HourData{Name: "home", Hour: weather.Hour{Time: "2025-01-10 08:00"}},
+		ReportRainWind:     HourData{Name: "home", Hour: weather.Hour{Time: "2025-01-10 08:00"}},
+		ReportSnow:         HourData{Name: "home", Hour: weather.Hour{Time: "2025-01-10 08:00"}},
+		ReportSleet:        HourData{Name: "home", Hour: weather.Hour{Time: "2025-01-10 08:00"}},
+		ReportThunder:      HourData{Name: "home", Hour: weather.Hour{Time: "2025-01-10 08:00"}},
+		ReportFreezingRain: HourData{Name: "home", Hour: weather.Hour{Time: "2025-01-10 08:00"}},
+		ReportWarning:      WarningData{Name: "home", Alert: weather.Alert{Headline: "Flood warning"}},
+		ReportOutlook:      OutlookData{Name: "home", Days: []OutlookDay{{Date: "2025-07-14"}}},
+		ReportDigest:       DigestData{Name: "home", Date: "2025-07-10", Summary: weather.Summarize(data.Hours, 50)},
+		ReportHeld:         data,
+		ReportCommute:      CommuteData{Name: "home", Commute: "morning", Start: "08:15", End: "08:45"},
+	}
+
+	t.Run("Every locale renders", func(t *testing.T) {
+		for tag, l := range locales {
+			for name := range builtinReports["en"] {
+				if _, _, err := l.Report(name, samples[name]); err != nil {
+					t.Errorf("%s/%s: unexpected error: %v", tag, name, err)
+				}
+			}
+		}
+	})
+
+	t.Run("Unknown report", func(t *testing.T) {
+		l, _ := LookupLocale("en")
+		if _, _, err := l.Report("nope", data); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
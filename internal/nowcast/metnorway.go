@@ -0,0 +1,54 @@
+package nowcast
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const MetNorwayURL = "https://api.met.no/weatherapi/nowcast/2.0/complete"
+
+// MetNorway provides the radar-based nowcast of the Norwegian Meteorological
+// Institute, in 5 minute steps over the next 90 minutes. It only covers the
+// Nordic countries.
+type MetNorway struct {
+	HttpClient HTTPClient
+	URL        string
+}
+
+func NewMetNorway(client HTTPClient, url string) *MetNorway {
+	return &MetNorway{HttpClient: client, URL: url}
+}
+
+func (m *MetNorway) Nowcast(ctx context.Context, lat, lon float64) (*Nowcast, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', 4, 64))
+	params.Set("lon", strconv.FormatFloat(lon, 'f', 4, 64))
+
+	var resp struct {
+		Properties struct {
+			Timeseries []struct {
+				Time time.Time `json:"time"`
+				Data struct {
+					Instant struct {
+						Details struct {
+							PrecipitationRate float64 `json:"precipitation_rate"` // mm/h
+						} `json:"details"`
+					} `json:"instant"`
+				} `json:"data"`
+			} `json:"timeseries"`
+		} `json:"properties"`
+	}
+	if err := get(ctx, m.HttpClient, fmt.Sprintf("%s?%s", m.URL, params.Encode()), &resp); err != nil {
+		return nil, err
+	}
+
+	n := &Nowcast{Interval: 5 * time.Minute}
+	for _, ts := range resp.Properties.Timeseries {
+		rate := ts.Data.Instant.Details.PrecipitationRate
+		n.Steps = append(n.Steps, Step{Time: ts.Time, PrecipMM: rate * n.Interval.Hours()})
+	}
+	return n, nil
+}
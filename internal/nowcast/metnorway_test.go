@@ -0,0 +1,37 @@
+package nowcast
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMetNorway(t *testing.T) {
+	body := `{"properties": {"timeseries": [
+		{"time": "2025-07-10T14:00:00Z", "data": {"instant": {"details": {"precipitation_rate": 0}}}},
+		{"time": "2025-07-10T14:05:00Z", "data": {"instant": {"details": {"precipitation_rate": 2.4}}}}
+	]}}`
+	var userAgent string
+	client := NewMockClient(http.StatusOK, body)
+	do := client.DoFunc
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		userAgent = req.Header.Get("User-Agent")
+		return do(req)
+	}
+
+	n, err := NewMetNorway(client, "http://test.com").Nowcast(context.Background(), 59.9139, 10.7522)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if userAgent == "" {
+		t.Error("expected a User-Agent, as required by the API")
+	}
+	if n.Interval != 5*time.Minute || len(n.Steps) != 2 {
+		t.Fatalf("unexpected nowcast %+v", n)
+	}
+	if mm := n.Steps[1].PrecipMM; mm < 0.199 || mm > 0.201 {
+		t.Errorf("expected 0.2 mm in 5 minutes at 2.4 mm/h, got %g", mm)
+	}
+}
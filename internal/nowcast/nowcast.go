@@ -0,0 +1,125 @@
+// Package nowcast fetches sub-hourly precipitation forecasts of the next
+// hours, to tell when rain starts and how long it lasts.
+package nowcast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Providers of nowcasts.
+const (
+	ProviderOpenMeteo = "open-meteo"
+	ProviderMetNorway = "met-norway"
+)
+
+const userAgent = "rain-alert/1.0 github.com/imedgar/rain-alert"
+
+// WetMMPerHour is the precipitation rate from which a step is wet.
+const WetMMPerHour = 0.1
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Provider returns the nowcast at a point.
+type Provider interface {
+	Nowcast(ctx context.Context, lat, lon float64) (*Nowcast, error)
+}
+
+// New returns the named provider using its public API.
+func New(provider string, client HTTPClient) (Provider, error) {
+	switch provider {
+	case ProviderOpenMeteo:
+		return NewOpenMeteo(client, OpenMeteoURL), nil
+	case ProviderMetNorway:
+		return NewMetNorway(client, MetNorwayURL), nil
+	default:
+		return nil, fmt.Errorf("unknown nowcast provider %q", provider)
+	}
+}
+
+// Step is the precipitation forecast for the Interval starting at Time.
+type Step struct {
+	Time     time.Time
+	PrecipMM float64
+}
+
+// Nowcast is a precipitation forecast in steps of equal length, oldest
+// first.
+type Nowcast struct {
+	Interval time.Duration
+	Steps    []Step
+}
+
+// Rain is a spell of rain.
+type Rain struct {
+	Start    time.Time
+	Duration time.Duration
+	TotalMM  float64
+	// Open is set when the rain lasts until the end of the nowcast, and may
+	// last longer.
+	Open bool
+}
+
+func (n *Nowcast) wet(s Step) bool {
+	return s.PrecipMM/n.Interval.Hours() >= WetMMPerHour
+}
+
+// NextRain returns the first spell of rain not over by now. Rain already
+// falling starts now.
+func (n *Nowcast) NextRain(now time.Time) (Rain, bool) {
+	var rain Rain
+	found := false
+	for _, s := range n.Steps {
+		end := s.Time.Add(n.Interval)
+		if !end.After(now) {
+			continue
+		}
+		if !n.wet(s) {
+			if found {
+				return rain, true
+			}
+			continue
+		}
+
+		if !found {
+			rain.Start = s.Time
+			if rain.Start.Before(now) {
+				rain.Start = now
+			}
+			found = true
+		}
+		rain.Duration = end.Sub(rain.Start)
+		rain.TotalMM += s.PrecipMM
+	}
+	rain.Open = found
+	return rain, found
+}
+
+// get decodes the JSON response of a GET request.
+func get(ctx context.Context, client HTTPClient, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package nowcast
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type MockClient struct {
+	DoFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
+	return m.DoFunc(req)
+}
+
+func NewMockClient(statusCode int, body string) *MockClient {
+	return &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: statusCode,
+				Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		},
+	}
+}
+
+// quarters returns a nowcast of 15 minute steps from start.
+func quarters(start time.Time, mm ...float64) *Nowcast {
+	n := &Nowcast{Interval: 15 * time.Minute}
+	for i, v := range mm {
+		n.Steps = append(n.Steps, Step{Time: start.Add(time.Duration(i) * n.Interval), PrecipMM: v})
+	}
+	return n
+}
+
+func TestNextRain(t *testing.T) {
+	start := time.Date(2025, 7, 10, 14, 0, 0, 0, time.UTC)
+
+	t.Run("Upcoming", func(t *testing.T) {
+		n := quarters(start, 0, 0, 0.4, 0.8, 0, 0.5)
+
+		rain, ok := n.NextRain(start.Add(5 * time.Minute))
+		if !ok {
+			t.Fatal("expected rain")
+		}
+		if !rain.Start.Equal(start.Add(30*time.Minute)) || rain.Duration != 30*time.Minute || rain.Open {
+			t.Errorf("unexpected rain %+v", rain)
+		}
+		if rain.TotalMM < 1.19 || rain.TotalMM > 1.21 {
+			t.Errorf("expected 1.2 mm, got %g", rain.TotalMM)
+		}
+	})
+
+	t.Run("Already raining", func(t *testing.T) {
+		n := quarters(start, 0.5, 0.5, 0.5)
+
+		rain, ok := n.NextRain(start.Add(10 * time.Minute))
+		if !ok || !rain.Start.Equal(start.Add(10*time.Minute)) || rain.Duration != 35*time.Minute || !rain.Open {
+			t.Errorf("unexpected rain %+v, %t", rain, ok)
+		}
+	})
+
+	t.Run("Drizzle below the wet rate", func(t *testing.T) {
+		n := quarters(start, 0, 0.01, 0)
+
+		if rain, ok := n.NextRain(start); ok {
+			t.Errorf("expected no rain, got %+v", rain)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{ProviderOpenMeteo, ProviderMetNorway} {
+		if _, err := New(name, http.DefaultClient); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := New("nope", http.DefaultClient); err == nil {
+		t.Error("expected an error, but got nil")
+	}
+}
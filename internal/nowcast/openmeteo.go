@@ -0,0 +1,56 @@
+package nowcast
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const OpenMeteoURL = "https://api.open-meteo.com/v1/forecast"
+
+// openMeteoSteps is how many 15 minute steps are requested, three hours.
+const openMeteoSteps = 12
+
+// OpenMeteo provides the 15 minute precipitation forecast of Open-Meteo,
+// available worldwide.
+type OpenMeteo struct {
+	HttpClient HTTPClient
+	URL        string
+}
+
+func NewOpenMeteo(client HTTPClient, url string) *OpenMeteo {
+	return &OpenMeteo{HttpClient: client, URL: url}
+}
+
+func (o *OpenMeteo) Nowcast(ctx context.Context, lat, lon float64) (*Nowcast, error) {
+	params := url.Values{}
+	params.Set("latitude", strconv.FormatFloat(lat, 'f', 4, 64))
+	params.Set("longitude", strconv.FormatFloat(lon, 'f', 4, 64))
+	params.Set("minutely_15", "precipitation")
+	params.Set("forecast_minutely_15", strconv.Itoa(openMeteoSteps))
+	params.Set("timeformat", "unixtime")
+
+	var resp struct {
+		Minutely15 struct {
+			Time          []int64   `json:"time"`
+			Precipitation []float64 `json:"precipitation"`
+		} `json:"minutely_15"`
+	}
+	if err := get(ctx, o.HttpClient, fmt.Sprintf("%s?%s", o.URL, params.Encode()), &resp); err != nil {
+		return nil, err
+	}
+
+	m := resp.Minutely15
+	if len(m.Time) != len(m.Precipitation) {
+		return nil, fmt.Errorf("got %d times for %d precipitation steps", len(m.Time), len(m.Precipitation))
+	}
+
+	// Each step's precipitation is the sum of the 15 minutes before its time.
+	n := &Nowcast{Interval: 15 * time.Minute}
+	for i, t := range m.Time {
+		n.Steps = append(n.Steps, Step{Time: time.Unix(t, 0).Add(-n.Interval), PrecipMM: m.Precipitation[i]})
+	}
+	return n, nil
+}
@@ -0,0 +1,52 @@
+package nowcast
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOpenMeteo(t *testing.T) {
+	t.Run("Successful nowcast", func(t *testing.T) {
+		var query string
+		client := NewMockClient(http.StatusOK, `{"minutely_15": {"time": [1752156000, 1752156900], "precipitation": [0, 0.6]}}`)
+		do := client.DoFunc
+		client.DoFunc = func(req *http.Request) (*http.Response, error) {
+			query = req.URL.RawQuery
+			return do(req)
+		}
+
+		n, err := NewOpenMeteo(client, "http://test.com").Nowcast(context.Background(), 40.4168, -3.7038)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if query != "forecast_minutely_15=12&latitude=40.4168&longitude=-3.7038&minutely_15=precipitation&timeformat=unixtime" {
+			t.Errorf("unexpected query %q", query)
+		}
+		if n.Interval != 15*time.Minute || len(n.Steps) != 2 || n.Steps[1].PrecipMM != 0.6 {
+			t.Errorf("unexpected nowcast %+v", n)
+		}
+		// 1752156900 is 14:15 UTC, the end of the step with rain.
+		if start := time.Date(2025, 7, 10, 14, 0, 0, 0, time.UTC); !n.Steps[1].Time.Equal(start) {
+			t.Errorf("expected the rain to start at %s, got %s", start, n.Steps[1].Time)
+		}
+	})
+
+	t.Run("Mismatched steps", func(t *testing.T) {
+		client := NewMockClient(http.StatusOK, `{"minutely_15": {"time": [1752156000], "precipitation": []}}`)
+
+		if _, err := NewOpenMeteo(client, "http://test.com").Nowcast(context.Background(), 40.4, -3.7); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		client := NewMockClient(http.StatusBadRequest, `{"error": true}`)
+
+		if _, err := NewOpenMeteo(client, "http://test.com").Nowcast(context.Background(), 40.4, -3.7); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
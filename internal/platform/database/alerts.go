@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AlertVersions returns the versions of a weather alert forwarded so far,
+// none if it never was.
+func (db *DB) AlertVersions(ctx context.Context, location string, subscriptionID int64, alertID string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM weather_alerts WHERE location = ? AND subscription_id = ? AND alert_id = ?",
+		location, subscriptionID, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("querying weather alert: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("scanning weather alert: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying weather alert: %w", err)
+	}
+	return versions, nil
+}
+
+// RecordAlert stores a version of a weather alert as forwarded. Every
+// version is kept, as warnings with the same event and areas, such as
+// today's and tomorrow's, share an ID.
+func (db *DB) RecordAlert(ctx context.Context, location string, subscriptionID int64, alertID, version string) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO weather_alerts(location, subscription_id, alert_id, version, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(location, subscription_id, alert_id, version) DO NOTHING`,
+		location, subscriptionID, alertID, version, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("inserting weather alert: %w", err)
+	}
+	return nil
+}
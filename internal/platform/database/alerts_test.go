@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAlertVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Never forwarded", func(t *testing.T) {
+		mock.ExpectQuery("SELECT version FROM weather_alerts").
+			WithArgs("office", 1, "abc").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+		versions, err := dbMock.AlertVersions(context.Background(), "office", 1, "abc")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if len(versions) != 0 {
+			t.Errorf("expected no versions, got %v", versions)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Forwarded", func(t *testing.T) {
+		mock.ExpectQuery("SELECT version FROM weather_alerts").
+			WithArgs("office", 1, "abc").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("v1").AddRow("v2"))
+
+		versions, err := dbMock.AlertVersions(context.Background(), "office", 1, "abc")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !slices.Equal(versions, []string{"v1", "v2"}) {
+			t.Errorf("expected versions v1 and v2, got %v", versions)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestRecordAlert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	mock.ExpectExec("INSERT INTO weather_alerts").
+		WithArgs("office", 1, "abc", "v2", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := dbMock.RecordAlert(context.Background(), "office", 1, "abc", "v2"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DailyNotified reports whether a once-a-day notification of the given kind,
+// such as a commute alert or the digest, was already sent on the given day
+// (YYYY-MM-DD, local to the location).
+func (db *DB) DailyNotified(ctx context.Context, location string, subscriptionID int64, kind, day string) (bool, error) {
+	var id int64
+	row := db.QueryRowContext(ctx, "SELECT id FROM daily_notifications WHERE location = ? AND subscription_id = ? AND kind = ? AND day = ? LIMIT 1",
+		location, subscriptionID, kind, day)
+	if err := row.Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("querying daily notification: %w", err)
+	}
+	return true, nil
+}
+
+func (db *DB) RecordDailyNotification(ctx context.Context, location string, subscriptionID int64, kind, day string) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO daily_notifications(location, subscription_id, kind, day, created_at) VALUES (?, ?, ?, ?, ?)",
+		location, subscriptionID, kind, day, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("inserting daily notification: %w", err)
+	}
+	return nil
+}
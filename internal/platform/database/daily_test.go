@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDailyNotified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Not notified", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM daily_notifications").
+			WithArgs("office", 1, "commute:morning", "2025-07-11").
+			WillReturnError(sql.ErrNoRows)
+
+		notified, err := dbMock.DailyNotified(context.Background(), "office", 1, "commute:morning", "2025-07-11")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if notified {
+			t.Error("expected not notified")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Already notified", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM daily_notifications").
+			WithArgs("office", 1, "commute:morning", "2025-07-11").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+		notified, err := dbMock.DailyNotified(context.Background(), "office", 1, "commute:morning", "2025-07-11")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !notified {
+			t.Error("expected notified")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestRecordDailyNotification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	mock.ExpectExec("INSERT INTO daily_notifications").
+		WithArgs("office", 1, "commute:morning", "2025-07-11", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := dbMock.RecordDailyNotification(context.Background(), "office", 1, "commute:morning", "2025-07-11"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
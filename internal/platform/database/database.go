@@ -1,23 +1,49 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type DB struct {
 	*sql.DB
+
+	// ReadOnly turns every write into a no-op, for dry runs.
+	ReadOnly bool
 }
 
 func New(db *sql.DB) *DB {
-	return &DB{db}
+	return &DB{DB: db}
+}
+
+// missingTable reports whether err is SQLite's for querying a table that
+// does not exist, so optional tables can be left out.
+func missingTable(err error, table string) bool {
+	return strings.Contains(err.Error(), "no such table: "+table)
 }
 
-func (db *DB) GetThresholds() (map[string]int, error) {
-	rows, err := db.Query("SELECT config, value FROM weather_config")
+// ExecContext runs a write, unless the database is read-only.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if db.ReadOnly {
+		return driver.RowsAffected(0), nil
+	}
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// Exec is ExecContext without a context. It is overridden so that no write
+// bypasses ReadOnly.
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *DB) GetThresholds(ctx context.Context) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT config, value FROM weather_config")
 	if err != nil {
 		return nil, fmt.Errorf("querying config: %w", err)
 	}
@@ -44,36 +70,114 @@ func (db *DB) GetThresholds() (map[string]int, error) {
 	return configs, nil
 }
 
-func (db *DB) ShouldNotify(thresholds map[string]int) (bool, error) {
+// ShouldNotify checks the notification history of a kind of alert, such as
+// rain or wind, at a location for one subscription, subscription 0 being the
+// location's own topics. It is suppressed while the last notification is
+// within the cooldown, unless repeat, given its state, sends it again. The
+// reason explains the decision.
+func (db *DB) ShouldNotify(ctx context.Context, location string, subscriptionID int64, kind string, cooldown time.Duration, repeat func(state int) (bool, error)) (notify bool, reason string, err error) {
 	var state int
 	var createdAt int64
 
-	row := db.QueryRow("SELECT state, created_at FROM weather_notifications ORDER BY id DESC LIMIT 1")
-	err := row.Scan(&state, &createdAt)
-	if err != nil {
+	row := db.QueryRowContext(ctx, "SELECT state, created_at FROM weather_notifications WHERE location = ? AND subscription_id = ? AND kind = ? ORDER BY id DESC LIMIT 1", location, subscriptionID, kind)
+	if err := row.Scan(&state, &createdAt); err != nil {
 		if err == sql.ErrNoRows {
-			return true, nil
+			return true, fmt.Sprintf("no previous %s notification", kind), nil
 		}
-		return false, fmt.Errorf("querying last notification: %w", err)
+		return false, "", fmt.Errorf("querying last notification: %w", err)
 	}
 
 	age := time.Since(time.Unix(createdAt, 0))
-	if age > time.Hour {
-		log.Println("Last notification is older than 1 hour, ignoring previous state.")
-		return true, nil
+	if age > cooldown {
+		log.Printf("Last %s notification for %s is older than %s, ignoring previous state.\n", kind, location, cooldown)
+	}
+
+	return CheckCooldown(kind, state, age, cooldown, repeat)
+}
+
+// CheckCooldown decides on an alert given the last notification of its kind,
+// sent age ago with state, as ShouldNotify does. The age is rounded to the
+// minute in the reason only.
+func CheckCooldown(kind string, state int, age, cooldown time.Duration, repeat func(state int) (bool, error)) (notify bool, reason string, err error) {
+	ago := age.Round(time.Minute)
+	if age > cooldown {
+		return true, fmt.Sprintf("last %s notification %s ago, outside the %s cooldown", kind, ago, cooldown), nil
 	}
 
-	if state > thresholds["rainBeforeThreshold"] {
-		return false, nil
+	again, err := repeat(state)
+	if err != nil {
+		return false, "", err
+	}
+	if !again {
+		return false, fmt.Sprintf("last %s notification %s ago had state %d, not repeated within the %s cooldown", kind, ago, state, cooldown), nil
 	}
 
-	return true, nil
+	return true, fmt.Sprintf("last %s notification %s ago had state %d, repeated", kind, ago, state), nil
 }
 
-func (db *DB) RecordNotification(state int) error {
-	_, err := db.Exec("INSERT INTO weather_notifications(state, created_at) VALUES (?, ?)", state, time.Now().Unix())
+func (db *DB) RecordNotification(ctx context.Context, location string, subscriptionID int64, kind string, state int) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO weather_notifications(location, subscription_id, kind, state, created_at) VALUES (?, ?, ?, ?, ?)", location, subscriptionID, kind, state, time.Now().Unix())
 	if err != nil {
 		return fmt.Errorf("inserting notification: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (db *DB) GetMessageTemplates(ctx context.Context, set, locale string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT body FROM message_templates WHERE set_name = ? AND locale = ? ORDER BY id", set, locale)
+	if err != nil {
+		if missingTable(err, "message_templates") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("querying message templates: %w", err)
+	}
+	defer rows.Close()
+
+	var bodies []string
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&body); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		bodies = append(bodies, body)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return bodies, nil
+}
+
+// RecentMessages returns the keys of the last templates sent at a location
+// to one subscription, most recent first.
+func (db *DB) RecentMessages(ctx context.Context, location string, subscriptionID int64, limit int) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT template FROM message_history WHERE location = ? AND subscription_id = ? ORDER BY id DESC LIMIT ?", location, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying message history: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (db *DB) RecordMessage(ctx context.Context, location string, subscriptionID int64, key string) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO message_history(location, subscription_id, template, created_at) VALUES (?, ?, ?, ?)", location, subscriptionID, key, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("inserting message history: %w", err)
+	}
+	return nil
+}
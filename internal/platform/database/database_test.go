@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -22,7 +24,7 @@ func TestGetThresholds(t *testing.T) {
 			AddRow("rainBeforeThreshold", "70")
 		mock.ExpectQuery("SELECT config, value FROM weather_config").WillReturnRows(rows)
 
-		thresholds, err := dbMock.GetThresholds()
+		thresholds, err := dbMock.GetThresholds(context.Background())
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -46,6 +48,8 @@ func TestGetThresholds(t *testing.T) {
 }
 
 func TestShouldNotify(t *testing.T) {
+	notAbove70 := func(state int) (bool, error) { return state <= 70, nil }
+
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -55,9 +59,9 @@ func TestShouldNotify(t *testing.T) {
 	dbMock := New(db)
 
 	t.Run("No recent notifications", func(t *testing.T) {
-		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WillReturnRows(sqlmock.NewRows([]string{"state", "created_at"}))
+		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("home", 0, "rain").WillReturnRows(sqlmock.NewRows([]string{"state", "created_at"}))
 
-		notify, err := dbMock.ShouldNotify(map[string]int{"rainBeforeThreshold": 70})
+		notify, _, err := dbMock.ShouldNotify(context.Background(), "home", 0, "rain", time.Hour, notAbove70)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -73,9 +77,9 @@ func TestShouldNotify(t *testing.T) {
 
 	t.Run("Recent notification with low rain chance", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"state", "created_at"}).AddRow(60, time.Now().Unix())
-		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("home", 0, "rain").WillReturnRows(rows)
 
-		notify, err := dbMock.ShouldNotify(map[string]int{"rainBeforeThreshold": 70})
+		notify, _, err := dbMock.ShouldNotify(context.Background(), "home", 0, "rain", time.Hour, notAbove70)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -91,9 +95,9 @@ func TestShouldNotify(t *testing.T) {
 
 	t.Run("Recent notification with high rain chance", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"state", "created_at"}).AddRow(80, time.Now().Unix())
-		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT state, created_at FROM weather_notifications").WithArgs("home", 0, "rain").WillReturnRows(rows)
 
-		notify, err := dbMock.ShouldNotify(map[string]int{"rainBeforeThreshold": 70})
+		notify, reason, err := dbMock.ShouldNotify(context.Background(), "home", 0, "rain", time.Hour, notAbove70)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -101,6 +105,9 @@ func TestShouldNotify(t *testing.T) {
 		if notify {
 			t.Error("expected not to be notified, but it was")
 		}
+		if reason != "last rain notification 0s ago had state 80, not repeated within the 1h0m0s cooldown" {
+			t.Errorf("unexpected reason %q", reason)
+		}
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
@@ -119,14 +126,135 @@ func TestRecordNotification(t *testing.T) {
 
 	t.Run("Successful recording", func(t *testing.T) {
 		mock.ExpectExec("INSERT INTO weather_notifications").
-			WithArgs(80, sqlmock.AnyArg()).
+			WithArgs("home", 3, "rain", 80, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		err := dbMock.RecordNotification(80)
+		err := dbMock.RecordNotification(context.Background(), "home", 3, "rain", 80)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Read-only", func(t *testing.T) {
+		readOnly := New(db)
+		readOnly.ReadOnly = true
+
+		if err := readOnly.RecordNotification(context.Background(), "home", 3, "rain", 80); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("expected no write, got: %s", err)
+		}
+	})
+}
+
+func TestCheckCooldown(t *testing.T) {
+	never := func(int) (bool, error) { return false, nil }
+
+	notify, reason, err := CheckCooldown("rain", 80, time.Hour+25*time.Second, time.Hour, never)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !notify {
+		t.Error("expected a notification just past the cooldown")
+	}
+	if reason != "last rain notification 1h0m0s ago, outside the 1h0m0s cooldown" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+
+	if notify, _, _ := CheckCooldown("rain", 80, time.Hour, time.Hour, never); notify {
+		t.Error("expected no notification within the cooldown")
+	}
+}
+
+func TestGetMessageTemplates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Successful retrieval", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"body"}).
+			AddRow("Rain in {{.Location}}").
+			AddRow("Wet {{.Location}}")
+		mock.ExpectQuery("SELECT body FROM message_templates").WithArgs("serious", "de").WillReturnRows(rows)
+
+		bodies, err := dbMock.GetMessageTemplates(context.Background(), "serious", "de")
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
 
+		if len(bodies) != 2 {
+			t.Errorf("expected 2 templates, got %d", len(bodies))
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("No message_templates table", func(t *testing.T) {
+		mock.ExpectQuery("SELECT body FROM message_templates").WithArgs("serious", "de").
+			WillReturnError(errors.New("SQLite error: no such table: message_templates"))
+
+		bodies, err := dbMock.GetMessageTemplates(context.Background(), "serious", "de")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if len(bodies) != 0 {
+			t.Errorf("expected no templates, got %v", bodies)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestMessageHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Recent messages", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"template"}).AddRow("b").AddRow("a")
+		mock.ExpectQuery("SELECT template FROM message_history").WithArgs("home", 3, 2).WillReturnRows(rows)
+
+		keys, err := dbMock.RecentMessages(context.Background(), "home", 3, 2)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if len(keys) != 2 || keys[0] != "b" {
+			t.Errorf("expected [b a], got %v", keys)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Record message", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO message_history").
+			WithArgs("home", 3, "b", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		if err := dbMock.RecordMessage(context.Background(), "home", 3, "b"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
 		}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HeldNotification is an alert kept back during quiet hours.
+type HeldNotification struct {
+	HourTime     string
+	ChanceOfRain int
+	PrecipMM     float64
+	CreatedAt    time.Time
+}
+
+func (db *DB) HoldNotification(ctx context.Context, location string, subscriptionID int64, n HeldNotification) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO held_notifications(location, subscription_id, hour_time, chance_of_rain, precip_mm, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		location, subscriptionID, n.HourTime, n.ChanceOfRain, n.PrecipMM, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("inserting held notification: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetHeldNotifications(ctx context.Context, location string, subscriptionID int64) ([]HeldNotification, error) {
+	rows, err := db.QueryContext(ctx, "SELECT hour_time, chance_of_rain, precip_mm, created_at FROM held_notifications WHERE location = ? AND subscription_id = ? ORDER BY id",
+		location, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("querying held notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var held []HeldNotification
+	for rows.Next() {
+		var n HeldNotification
+		var createdAt int64
+		if err := rows.Scan(&n.HourTime, &n.ChanceOfRain, &n.PrecipMM, &createdAt); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		n.CreatedAt = time.Unix(createdAt, 0)
+		held = append(held, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return held, nil
+}
+
+func (db *DB) ClearHeldNotifications(ctx context.Context, location string, subscriptionID int64) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM held_notifications WHERE location = ? AND subscription_id = ?", location, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("deleting held notifications: %w", err)
+	}
+	return nil
+}
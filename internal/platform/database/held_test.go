@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHeldNotifications(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Hold", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO held_notifications").
+			WithArgs("home", 2, "2025-07-10 03:00", 80, 1.5, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := dbMock.HoldNotification(context.Background(), "home", 2, HeldNotification{HourTime: "2025-07-10 03:00", ChanceOfRain: 80, PrecipMM: 1.5})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"hour_time", "chance_of_rain", "precip_mm", "created_at"}).
+			AddRow("2025-07-10 03:00", 80, 1.5, time.Now().Unix())
+		mock.ExpectQuery("SELECT (.+) FROM held_notifications").WithArgs("home", 2).WillReturnRows(rows)
+
+		held, err := dbMock.GetHeldNotifications(context.Background(), "home", 2)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if len(held) != 1 || held[0].ChanceOfRain != 80 {
+			t.Errorf("unexpected held notifications %+v", held)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM held_notifications").WithArgs("home", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := dbMock.ClearHeldNotifications(context.Background(), "home", 2); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
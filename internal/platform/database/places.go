@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Place is a geocoded location, cached under the search it resolves.
+type Place struct {
+	Name    string
+	Region  string
+	Country string
+	Lat     float64
+	Lon     float64
+}
+
+// GetPlace returns the place cached for a search, or nil if there is none.
+func (db *DB) GetPlace(ctx context.Context, search string) (*Place, error) {
+	var p Place
+	row := db.QueryRowContext(ctx, "SELECT name, region, country, lat, lon FROM places WHERE search = ?", search)
+	if err := row.Scan(&p.Name, &p.Region, &p.Country, &p.Lat, &p.Lon); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("querying place: %w", err)
+	}
+	return &p, nil
+}
+
+// RecordPlace caches the place a search resolved to.
+func (db *DB) RecordPlace(ctx context.Context, search string, p Place) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO places(search, name, region, country, lat, lon, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(search) DO UPDATE SET name = excluded.name, region = excluded.region, country = excluded.country, lat = excluded.lat, lon = excluded.lon, created_at = excluded.created_at`,
+		search, p.Name, p.Region, p.Country, p.Lat, p.Lon, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("storing place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetPlace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Not cached", func(t *testing.T) {
+		mock.ExpectQuery("SELECT name, region, country, lat, lon FROM places").
+			WithArgs("Springfield|Illinois|").
+			WillReturnError(sql.ErrNoRows)
+
+		place, err := dbMock.GetPlace(context.Background(), "Springfield|Illinois|")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if place != nil {
+			t.Errorf("expected no place, got %+v", place)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		mock.ExpectQuery("SELECT name, region, country, lat, lon FROM places").
+			WithArgs("Springfield|Illinois|").
+			WillReturnRows(sqlmock.NewRows([]string{"name", "region", "country", "lat", "lon"}).
+				AddRow("Springfield", "Illinois", "United States of America", 39.8, -89.64))
+
+		place, err := dbMock.GetPlace(context.Background(), "Springfield|Illinois|")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if place == nil || place.Region != "Illinois" || place.Lat != 39.8 {
+			t.Errorf("unexpected place %+v", place)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestRecordPlace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	mock.ExpectExec("INSERT INTO places").
+		WithArgs("Springfield|Illinois|", "Springfield", "Illinois", "United States of America", 39.8, -89.64, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	place := Place{Name: "Springfield", Region: "Illinois", Country: "United States of America", Lat: 39.8, Lon: -89.64}
+	if err := dbMock.RecordPlace(context.Background(), "Springfield|Illinois|", place); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// AlertRule is an alert rule definition, see the rule package.
+type AlertRule struct {
+	Name      string
+	Condition string
+	Exit      string // exit condition, for hysteresis
+	Repeat    string // condition to send again within the cooldown
+	Severity  string
+	Cooldown  string // duration such as "3h"
+	Title     string
+	Message   string
+}
+
+// GetAlertRules returns the enabled alert rules, none without the optional
+// alert_rules table.
+func (db *DB) GetAlertRules(ctx context.Context) ([]AlertRule, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, condition, exit_condition, repeat_condition, severity, cooldown, title, message FROM alert_rules WHERE enabled = 1 ORDER BY id")
+	if err != nil {
+		if missingTable(err, "alert_rules") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("querying alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []AlertRule
+	for rows.Next() {
+		var r AlertRule
+		var exit, repeat, severity, cooldown, title, message sql.NullString
+		if err := rows.Scan(&r.Name, &r.Condition, &exit, &repeat, &severity, &cooldown, &title, &message); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		r.Exit = exit.String
+		r.Repeat = repeat.String
+		r.Severity = severity.String
+		r.Cooldown = cooldown.String
+		r.Title = title.String
+		r.Message = message.String
+		rules = append(rules, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return rules, nil
+}
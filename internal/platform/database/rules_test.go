@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetAlertRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	rows := sqlmock.NewRows([]string{"name", "condition", "exit_condition", "repeat_condition", "severity", "cooldown", "title", "message"}).
+		AddRow("windy-ride", "gust_kph >= 45", nil, nil, "moderate", "3h", "Windy ride", "Gusts in {{.Name}}").
+		AddRow("rain", "chance >= 60", "chance < 40", "last_chance < 60", nil, nil, nil, nil)
+	mock.ExpectQuery("FROM alert_rules WHERE enabled = 1").WillReturnRows(rows)
+
+	rules, err := dbMock.GetAlertRules(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Cooldown != "3h" || rules[1].Condition != "chance >= 60" || rules[1].Exit != "chance < 40" || rules[1].Repeat != "last_chance < 60" || rules[1].Message != "" {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetAlertRulesMissingTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	mock.ExpectQuery("FROM alert_rules WHERE enabled = 1").WillReturnError(errors.New("SQLite error: no such table: alert_rules"))
+
+	rules, err := dbMock.GetAlertRules(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(rules) != 0 {
+		t.Errorf("expected no rules, got %+v", rules)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
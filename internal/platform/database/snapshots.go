@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ForecastSnapshot is a forecast stored as fetched, to be replayed.
+type ForecastSnapshot struct {
+	CreatedAt time.Time
+	Body      []byte
+}
+
+// RecordSnapshot stores a location's forecast, as JSON.
+func (db *DB) RecordSnapshot(ctx context.Context, location string, body []byte) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO forecast_snapshots(location, body, created_at) VALUES (?, ?, ?)", location, string(body), time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("inserting forecast snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetSnapshots returns a location's forecasts stored since a time, oldest
+// first.
+func (db *DB) GetSnapshots(ctx context.Context, location string, since time.Time) ([]ForecastSnapshot, error) {
+	rows, err := db.QueryContext(ctx, "SELECT body, created_at FROM forecast_snapshots WHERE location = ? AND created_at >= ? ORDER BY created_at", location, since.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("querying forecast snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []ForecastSnapshot
+	for rows.Next() {
+		var body string
+		var createdAt int64
+		if err := rows.Scan(&body, &createdAt); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		snapshots = append(snapshots, ForecastSnapshot{CreatedAt: time.Unix(createdAt, 0), Body: []byte(body)})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return snapshots, nil
+}
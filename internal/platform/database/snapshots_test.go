@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSnapshots(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Record snapshot", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO forecast_snapshots").
+			WithArgs("office", `{"location":{}}`, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		if err := dbMock.RecordSnapshot(context.Background(), "office", []byte(`{"location":{}}`)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Get snapshots", func(t *testing.T) {
+		since := time.Unix(1752148800, 0)
+		rows := sqlmock.NewRows([]string{"body", "created_at"}).
+			AddRow(`{"a":1}`, 1752152700).
+			AddRow(`{"a":2}`, 1752156300)
+		mock.ExpectQuery("SELECT body, created_at FROM forecast_snapshots").WithArgs("office", since.Unix()).WillReturnRows(rows)
+
+		snapshots, err := dbMock.GetSnapshots(context.Background(), "office", since)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if len(snapshots) != 2 || string(snapshots[1].Body) != `{"a":2}` || snapshots[0].CreatedAt.Unix() != 1752152700 {
+			t.Errorf("unexpected snapshots %+v", snapshots)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
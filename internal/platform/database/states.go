@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AlertActive reports whether a hysteresis alert of a kind is active for a
+// subscription, that is it was sent and its exit condition has not been met
+// since.
+func (db *DB) AlertActive(ctx context.Context, location string, subscriptionID int64, kind string) (bool, error) {
+	var active bool
+	row := db.QueryRowContext(ctx, "SELECT active FROM alert_states WHERE location = ? AND subscription_id = ? AND kind = ?",
+		location, subscriptionID, kind)
+	if err := row.Scan(&active); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("querying alert state: %w", err)
+	}
+	return active, nil
+}
+
+// SetAlertActive stores the state of a hysteresis alert.
+func (db *DB) SetAlertActive(ctx context.Context, location string, subscriptionID int64, kind string, active bool) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO alert_states(location, subscription_id, kind, active, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(location, subscription_id, kind) DO UPDATE SET active = excluded.active, updated_at = excluded.updated_at`,
+		location, subscriptionID, kind, active, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("storing alert state: %w", err)
+	}
+	return nil
+}
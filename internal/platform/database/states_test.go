@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAlertActive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("No state", func(t *testing.T) {
+		mock.ExpectQuery("SELECT active FROM alert_states").
+			WithArgs("office", 1, "rain").
+			WillReturnError(sql.ErrNoRows)
+
+		active, err := dbMock.AlertActive(context.Background(), "office", 1, "rain")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if active {
+			t.Error("expected an inactive alert")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Active", func(t *testing.T) {
+		mock.ExpectQuery("SELECT active FROM alert_states").
+			WithArgs("office", 1, "rain").
+			WillReturnRows(sqlmock.NewRows([]string{"active"}).AddRow(true))
+
+		active, err := dbMock.AlertActive(context.Background(), "office", 1, "rain")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !active {
+			t.Error("expected an active alert")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestSetAlertActive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	mock.ExpectExec("INSERT INTO alert_states").
+		WithArgs("office", 1, "rain", false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := dbMock.SetAlertActive(context.Background(), "office", 1, "rain", false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
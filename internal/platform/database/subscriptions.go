@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Subscription is a user's interest in a location. Zero values fall back to
+// the location's settings.
+type Subscription struct {
+	ID              int64
+	UserID          int64
+	UserName        string
+	Locale          string
+	Location        string
+	Topic           string
+	MessageSet      string
+	Thresholds      map[string]int
+	QuietHours      string // JSON array of quiet hours rules
+	Commutes        string // JSON array of commutes
+	DisableNextHour bool
+	DigestAt        string // HH:MM
+	Outlook         string // JSON outlook settings
+}
+
+func (db *DB) GetSubscriptions(ctx context.Context, location string) ([]Subscription, error) {
+	rows, err := db.QueryContext(ctx, `SELECT s.id, u.id, u.name, u.locale, s.location, s.topic, s.message_set,
+		s.drizzle_threshold, s.rain_before_threshold, s.quiet_hours, s.commutes, s.disable_next_hour, s.digest_at, s.outlook
+		FROM subscriptions s JOIN users u ON u.id = s.user_id
+		WHERE s.location = ? ORDER BY s.id`, location)
+	if err != nil {
+		return nil, fmt.Errorf("querying subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var s Subscription
+		var locale, messageSet, quietHours, commutes, digestAt, outlook sql.NullString
+		var drizzle, rainBefore sql.NullInt64
+		var disableNextHour sql.NullBool
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserName, &locale, &s.Location, &s.Topic, &messageSet,
+			&drizzle, &rainBefore, &quietHours, &commutes, &disableNextHour, &digestAt, &outlook); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		s.Locale = locale.String
+		s.MessageSet = messageSet.String
+		s.QuietHours = quietHours.String
+		s.Commutes = commutes.String
+		s.DisableNextHour = disableNextHour.Bool
+		s.DigestAt = digestAt.String
+		s.Outlook = outlook.String
+		s.Thresholds = make(map[string]int)
+		if drizzle.Valid {
+			s.Thresholds["drizzleThreshold"] = int(drizzle.Int64)
+		}
+		if rainBefore.Valid {
+			s.Thresholds["rainBeforeThreshold"] = int(rainBefore.Int64)
+		}
+		subs = append(subs, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row error: %w", err)
+	}
+
+	return subs, nil
+}
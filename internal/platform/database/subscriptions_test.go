@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetSubscriptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbMock := New(db)
+
+	t.Run("Successful retrieval", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "name", "locale", "location", "topic", "message_set",
+			"drizzle_threshold", "rain_before_threshold", "quiet_hours", "commutes", "disable_next_hour", "digest_at", "outlook"}).
+			AddRow(1, 10, "ana", "es", "office", "ana-rain", "serious", 60, nil, `[{"start": "22:00", "end": "07:00"}]`, nil, false, "07:00", `{"days": 7}`).
+			AddRow(2, 11, "jonas", nil, "office", "jonas-rain", nil, nil, nil, nil, `[{"name": "bike", "start": "08:15", "end": "08:45"}]`, true, nil, nil)
+		mock.ExpectQuery("SELECT (.+) FROM subscriptions").WithArgs("office").WillReturnRows(rows)
+
+		subs, err := dbMock.GetSubscriptions(context.Background(), "office")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(subs) != 2 {
+			t.Fatalf("expected 2 subscriptions, got %d", len(subs))
+		}
+
+		if subs[0].Locale != "es" || subs[0].Thresholds["drizzleThreshold"] != 60 || subs[0].QuietHours == "" || subs[0].DigestAt != "07:00" || subs[0].Outlook == "" {
+			t.Errorf("unexpected first subscription %+v", subs[0])
+		}
+		if _, ok := subs[0].Thresholds["rainBeforeThreshold"]; ok {
+			t.Error("expected unset threshold to be absent")
+		}
+		if subs[1].Locale != "" || len(subs[1].Thresholds) != 0 || !subs[1].DisableNextHour || subs[1].Commutes == "" {
+			t.Errorf("unexpected second subscription %+v", subs[1])
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+const (
+	DefaultTimeout = 10 * time.Second
+	DefaultRetries = 3
+	// DefaultBaseDelay is the backoff before the first retry, doubled on
+	// each of the next.
+	DefaultBaseDelay = 500 * time.Millisecond
+	// DefaultMaxDelay caps the backoff, and the Retry-After honored: a
+	// server asking to wait longer gets its response returned instead.
+	DefaultMaxDelay = 30 * time.Second
+)
+
+// Client is an HTTP client shared by the weather, nowcast and ntfy clients.
+// Each attempt has its own timeout, and failed attempts are retried with
+// jittered exponential backoff while the request's context allows.
+//
+// Idempotent requests are retried on connection errors, 429 and 5xx gateway
+// responses. Others, such as ntfy publishes, only on 429 and 503, which the
+// server answers without processing the request, so they are not sent twice.
+type Client struct {
+	HttpClient HTTPClient
+	// Timeout bounds each attempt, including reading the response body.
+	// Zero means none.
+	Timeout   time.Duration
+	Retries   int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func New(client HTTPClient, timeout time.Duration, retries int) *Client {
+	return &Client{HttpClient: client, Timeout: timeout, Retries: retries, BaseDelay: DefaultBaseDelay, MaxDelay: DefaultMaxDelay}
+}
+
+// Do sends the request, retrying it as needed, and returns the last
+// response or error.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(req)
+		err = redactError(err, req.URL)
+		if attempt >= c.Retries || !c.retryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if after > c.MaxDelay {
+					return resp, err
+				}
+				delay = after
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			reason = resp.Status
+		}
+		log.Printf("%s %s: %s, retrying in %s.\n", req.Method, redact(req.URL), reason, delay.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting to retry: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("request body cannot be resent")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("resending request body: %w", err)
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// redact drops the credentials and query string, which hold API keys, from a
+// URL so it can be logged.
+func redact(u *url.URL) string {
+	r := *u
+	r.User = nil
+	r.RawQuery = ""
+	r.ForceQuery = false
+	return r.String()
+}
+
+// redactError replaces the URL in the transport errors of the request with
+// its redacted form, as they are logged too.
+func redactError(err error, u *url.URL) error {
+	if uerr, ok := err.(*url.Error); ok {
+		return &url.Error{Op: uerr.Op, URL: redact(u), Err: uerr.Err}
+	}
+	return err
+}
+
+// attempt sends the request once, bounded by the timeout. The timeout is
+// released when the response body is closed.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	if c.Timeout <= 0 {
+		return c.HttpClient.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.Timeout)
+	resp, err := c.HttpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func (c *Client) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+
+	idempotent := isIdempotent(req)
+	if err != nil {
+		return idempotent
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// backoff returns a random delay up to BaseDelay doubled per attempt, capped
+// at MaxDelay ("full jitter"), so clients failing together do not retry
+// together.
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.MaxDelay
+	if attempt < 32 {
+		ceiling = min(c.BaseDelay<<attempt, c.MaxDelay)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
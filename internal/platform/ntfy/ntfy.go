@@ -1,37 +1,111 @@
 package ntfy
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
-
-	"golang.org/x/exp/rand"
 )
 
 type Client struct {
 	HttpClient HTTPClient
 	URL        string
 	Topic      string
+
+	// Token is an access token for protected topics. It takes precedence
+	// over Username and Password.
+	Token    string
+	Username string
+	Password string
 }
 
+// ErrUnauthorized is returned when the server rejects the credentials or
+// denies access to the topic.
+var ErrUnauthorized = errors.New("unauthorized")
+
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// Priority is the ntfy message priority, from 1 (min) to 5 (urgent).
+type Priority int
+
+const (
+	PriorityMin Priority = iota + 1
+	PriorityLow
+	PriorityDefault
+	PriorityHigh
+	PriorityUrgent
+)
+
+// Action is a user action button attached to a notification.
+// See https://docs.ntfy.sh/publish/#action-buttons.
+type Action struct {
+	Action string // "view", "http" or "broadcast"
+	Label  string
+	URL    string
+	Clear  bool
+}
+
+// String formats the action in ntfy's short header format.
+func (a Action) String() string {
+	parts := []string{a.Action, quote(a.Label)}
+	if a.URL != "" {
+		parts = append(parts, quote(a.URL))
+	}
+	if a.Clear {
+		parts = append(parts, "clear=true")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// quote wraps values containing the short format's separators in quotes,
+// single ones when the value has double quotes.
+func quote(v string) string {
+	if !strings.ContainsAny(v, ",;") {
+		return v
+	}
+	if strings.Contains(v, `"`) {
+		return "'" + v + "'"
+	}
+	return `"` + v + `"`
+}
+
+// Message is a notification published to a topic. Only Body is required,
+// zero values leave the corresponding header unset.
+type Message struct {
+	Topic    string // overrides the client's topic when set
+	Title    string
+	Body     string
+	Tags     []string
+	Priority Priority
+	Click    string
+	Actions  []Action
+	Icon     string
+	Markdown bool
+	Delay    string // e.g. "30m", "9am" or a unix timestamp
+}
+
 func New(client HTTPClient, url, topic string) *Client {
 	return &Client{HttpClient: client, URL: url, Topic: topic}
 }
 
-func (c *Client) Send(title, message, tags string) error {
-	url := fmt.Sprintf("%s/%s", c.URL, c.Topic)
-	req, err := http.NewRequest("POST", url, strings.NewReader(message))
+func (c *Client) Send(ctx context.Context, msg Message) error {
+	topic := c.Topic
+	if msg.Topic != "" {
+		topic = msg.Topic
+	}
+
+	url := fmt.Sprintf("%s/%s", c.URL, topic)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(msg.Body))
 	if err != nil {
 		return fmt.Errorf("creating notification request: %w", err)
 	}
-	req.Header.Set("Title", title)
-	req.Header.Set("Tags", tags)
+	setHeaders(req, msg)
+	c.setAuth(req)
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
@@ -39,28 +113,54 @@ func (c *Client) Send(title, message, tags string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("publishing to topic %q: %s: %w", topic, resp.Status, ErrUnauthorized)
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		return fmt.Errorf("notification failed: %s", resp.Status)
 	}
 
-	log.Printf("Notification sent: %s", message)
+	log.Printf("Notification sent: %s", msg.Body)
 	return nil
 }
 
-func (c *Client) GenerateRainMessage(location, timeStr string, precipMM float64, chanceOfRain int) string {
-	botRainMessages := []string{
-		"ALERT! Rain in %s at %s!\n%.2fmm expected.\nChance: %d%%\nGrab your umbrella or face the splash!",
-		"SKY LEAK! %s, %s — %.2fmm incoming!\nWetness odds: %d%%",
-		"RAIN TIME!\n%s, %s\n%.2fmm on the way.\nChance: %d%%\nRejoice or retreat!",
-		"UMBRELLA ALERT!\n%s at %s\n%.2fmm forecasted.\nRain chance: %d%%",
-		"NOT A DRILL!\nRain in %s at %s\n%.2fmm expected.\nChance: %d%%",
-		"☁️ WET MODE ACTIVATED ☁️\n%s, %s\nRain: %.2fmm\nChance: %d%%",
-		"MOISTURE INCOMING!\n%s, %s\n%.2fmm with %d%% chance\nGet poncho-ready!",
-		"DRENCH MODE: ON 💦\n%s, %s\n%.2fmm rain\n%d%% chance",
-		"DRYNESS ERROR!\n%s, %s\n%.2fmm of sogginess\nOdds: %d%%",
-		"⚠️ RAIN WARNING ⚠️\n%s, %s\n%.2fmm\nChance: %d%%\nStay dry or embrace the drip.",
-	}
-	rand.Seed(uint64(time.Now().UnixNano()))
-	template := botRainMessages[rand.Intn(len(botRainMessages))]
-	return fmt.Sprintf(template, location, timeStr, precipMM, chanceOfRain)
+func (c *Client) setAuth(req *http.Request) {
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+func setHeaders(req *http.Request, msg Message) {
+	if msg.Title != "" {
+		req.Header.Set("Title", msg.Title)
+	}
+	if len(msg.Tags) > 0 {
+		req.Header.Set("Tags", strings.Join(msg.Tags, ","))
+	}
+	if msg.Priority != 0 {
+		req.Header.Set("Priority", strconv.Itoa(int(msg.Priority)))
+	}
+	if msg.Click != "" {
+		req.Header.Set("Click", msg.Click)
+	}
+	if len(msg.Actions) > 0 {
+		actions := make([]string, len(msg.Actions))
+		for i, a := range msg.Actions {
+			actions[i] = a.String()
+		}
+		req.Header.Set("Actions", strings.Join(actions, "; "))
+	}
+	if msg.Icon != "" {
+		req.Header.Set("Icon", msg.Icon)
+	}
+	if msg.Markdown {
+		req.Header.Set("Markdown", "yes")
+	}
+	if msg.Delay != "" {
+		req.Header.Set("Delay", msg.Delay)
+	}
 }
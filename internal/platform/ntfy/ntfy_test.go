@@ -2,6 +2,8 @@ package ntfy
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"testing"
@@ -28,7 +30,7 @@ func TestSend(t *testing.T) {
 
 		ntfyClient := New(mockClient, "https://ntfy.sh", "test-topic")
 
-		err := ntfyClient.Send("Test Title", "Test Message", "test,tags")
+		err := ntfyClient.Send(context.Background(), Message{Title: "Test Title", Body: "Test Message", Tags: []string{"test", "tags"}})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -46,9 +48,146 @@ func TestSend(t *testing.T) {
 
 		ntfyClient := New(mockClient, "https://ntfy.sh", "test-topic")
 
-		err := ntfyClient.Send("Test Title", "Test Message", "test,tags")
+		err := ntfyClient.Send(context.Background(), Message{Title: "Test Title", Body: "Test Message", Tags: []string{"test", "tags"}})
 		if err == nil {
 			t.Error("expected an error, but got nil")
 		}
 	})
 }
+
+func TestSendHeaders(t *testing.T) {
+	var got http.Header
+	mockClient := &MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			got = req.Header
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		},
+	}
+
+	ntfyClient := New(mockClient, "https://ntfy.sh", "test-topic")
+
+	err := ntfyClient.Send(context.Background(), Message{
+		Title:    "Rain Alert",
+		Body:     "**Rain** soon",
+		Tags:     []string{"umbrella", "robot"},
+		Priority: PriorityHigh,
+		Click:    "https://radar.example.com",
+		Actions:  []Action{{Action: "view", Label: "View radar", URL: "https://radar.example.com", Clear: true}},
+		Icon:     "https://example.com/icon.png",
+		Markdown: true,
+		Delay:    "30m",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"Title":    "Rain Alert",
+		"Tags":     "umbrella,robot",
+		"Priority": "4",
+		"Click":    "https://radar.example.com",
+		"Actions":  "view, View radar, https://radar.example.com, clear=true",
+		"Icon":     "https://example.com/icon.png",
+		"Markdown": "yes",
+		"Delay":    "30m",
+	}
+	for header, want := range expected {
+		if got.Get(header) != want {
+			t.Errorf("expected %s header to be '%s', got '%s'", header, want, got.Get(header))
+		}
+	}
+}
+
+func TestActionString(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   Action
+		expected string
+	}{
+		{"Plain", Action{Action: "view", Label: "Radar", URL: "https://radar.example.com"}, "view, Radar, https://radar.example.com"},
+		{"Comma in label", Action{Action: "view", Label: "Rain, now", URL: "https://radar.example.com"}, `view, "Rain, now", https://radar.example.com`},
+		{"Semicolon in URL", Action{Action: "http", Label: "Snooze", URL: "https://example.com/snooze?a=1;b=2"}, `http, Snooze, "https://example.com/snooze?a=1;b=2"`},
+		{"Double quotes", Action{Action: "view", Label: `"Heavy", rain`, URL: "https://radar.example.com"}, `view, '"Heavy", rain', https://radar.example.com`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.action.String(); got != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSendAuth(t *testing.T) {
+	t.Run("Access token", func(t *testing.T) {
+		var auth string
+		mockClient := &MockClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				auth = req.Header.Get("Authorization")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+				}, nil
+			},
+		}
+
+		ntfyClient := New(mockClient, "https://ntfy.example.com", "test-topic")
+		ntfyClient.Token = "tk_test"
+
+		if err := ntfyClient.Send(context.Background(), Message{Body: "Test Message"}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if auth != "Bearer tk_test" {
+			t.Errorf("expected bearer authorization, got '%s'", auth)
+		}
+	})
+
+	t.Run("Basic auth", func(t *testing.T) {
+		var user, pass string
+		mockClient := &MockClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				user, pass, _ = req.BasicAuth()
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+				}, nil
+			},
+		}
+
+		ntfyClient := New(mockClient, "https://ntfy.example.com", "test-topic")
+		ntfyClient.Username = "phil"
+		ntfyClient.Password = "secret"
+
+		if err := ntfyClient.Send(context.Background(), Message{Body: "Test Message"}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if user != "phil" || pass != "secret" {
+			t.Errorf("expected basic auth phil:secret, got %s:%s", user, pass)
+		}
+	})
+
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			mockClient := &MockClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: status,
+						Status:     http.StatusText(status),
+						Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+					}, nil
+				},
+			}
+
+			ntfyClient := New(mockClient, "https://ntfy.example.com", "test-topic")
+
+			err := ntfyClient.Send(context.Background(), Message{Body: "Test Message"})
+			if !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("expected ErrUnauthorized, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,465 @@
+package rule
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// An expression is a boolean condition over the fields of a forecast hour,
+// such as `chance_of_rain >= $drizzleThreshold and gust_kph > 40`. Names
+// starting with $ refer to thresholds. It is parsed and type checked once,
+// when the rule is loaded.
+
+type kind int
+
+const (
+	kindNumber kind = iota
+	kindBool
+	kindText
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "boolean"
+	case kindText:
+		return "text"
+	default:
+		return "number"
+	}
+}
+
+type value struct {
+	num  float64
+	text string
+	b    bool
+}
+
+type node interface {
+	kind() kind
+	eval(e env) (value, error)
+}
+
+type numberLit float64
+
+func (n numberLit) kind() kind              { return kindNumber }
+func (n numberLit) eval(env) (value, error) { return value{num: float64(n)}, nil }
+
+type textLit string
+
+func (t textLit) kind() kind              { return kindText }
+func (t textLit) eval(env) (value, error) { return value{text: string(t)}, nil }
+
+type boolLit bool
+
+func (b boolLit) kind() kind              { return kindBool }
+func (b boolLit) eval(env) (value, error) { return value{b: bool(b)}, nil }
+
+type fieldRef struct{ f field }
+
+func (r fieldRef) kind() kind                { return r.f.kind }
+func (r fieldRef) eval(e env) (value, error) { return r.f.get(e.hour), nil }
+
+type thresholdRef string
+
+func (t thresholdRef) kind() kind { return kindNumber }
+func (t thresholdRef) eval(e env) (value, error) {
+	v, ok := e.thresholds[string(t)]
+	if !ok {
+		return value{}, fmt.Errorf("threshold %q not configured", string(t))
+	}
+	return value{num: float64(v)}, nil
+}
+
+// lastChanceRef is last_chance, which only repeat conditions can use.
+type lastChanceRef struct{}
+
+func (lastChanceRef) kind() kind                { return kindNumber }
+func (lastChanceRef) eval(e env) (value, error) { return value{num: float64(e.lastChance)}, nil }
+
+type notNode struct{ x node }
+
+func (n notNode) kind() kind { return kindBool }
+func (n notNode) eval(e env) (value, error) {
+	v, err := n.x.eval(e)
+	return value{b: !v.b}, err
+}
+
+type negNode struct{ x node }
+
+func (n negNode) kind() kind { return kindNumber }
+func (n negNode) eval(e env) (value, error) {
+	v, err := n.x.eval(e)
+	return value{num: -v.num}, err
+}
+
+type binary struct {
+	op   string
+	x, y node
+}
+
+func (b binary) kind() kind {
+	switch b.op {
+	case "+", "-", "*", "/":
+		return kindNumber
+	default:
+		return kindBool
+	}
+}
+
+func (b binary) eval(e env) (value, error) {
+	x, err := b.x.eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	// and/or short-circuit.
+	switch {
+	case b.op == "and" && !x.b:
+		return value{b: false}, nil
+	case b.op == "or" && x.b:
+		return value{b: true}, nil
+	}
+	y, err := b.y.eval(e)
+	if err != nil {
+		return value{}, err
+	}
+
+	switch b.op {
+	case "and", "or":
+		return value{b: y.b}, nil
+	case "+":
+		return value{num: x.num + y.num}, nil
+	case "-":
+		return value{num: x.num - y.num}, nil
+	case "*":
+		return value{num: x.num * y.num}, nil
+	case "/":
+		if y.num == 0 {
+			return value{}, fmt.Errorf("division by zero")
+		}
+		return value{num: x.num / y.num}, nil
+	case "==":
+		return value{b: x == y}, nil
+	case "!=":
+		return value{b: x != y}, nil
+	case "<":
+		return value{b: x.num < y.num}, nil
+	case "<=":
+		return value{b: x.num <= y.num}, nil
+	case ">":
+		return value{b: x.num > y.num}, nil
+	default: // ">="
+		return value{b: x.num >= y.num}, nil
+	}
+}
+
+// thresholdNames returns the sorted names of the thresholds the expressions
+// refer to.
+func thresholdNames(nodes ...node) []string {
+	seen := map[string]bool{}
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case thresholdRef:
+			seen[string(n)] = true
+		case notNode:
+			walk(n.x)
+		case negNode:
+			walk(n.x)
+		case binary:
+			walk(n.x)
+			walk(n.y)
+		}
+	}
+	for _, n := range nodes {
+		if n != nil {
+			walk(n)
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// parse compiles an expression, which must be a boolean condition.
+func parse(src string) (node, error) {
+	return parseWith(&parser{}, src)
+}
+
+// parseRepeat compiles a repeat condition, which can also use last_chance.
+func parseRepeat(src string) (node, error) {
+	return parseWith(&parser{lastChance: true}, src)
+}
+
+func parseWith(p *parser, src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p.tokens = tokens
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != "" {
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+	if n.kind() != kindBool {
+		return nil, fmt.Errorf("expression is a %s, not a condition", n.kind())
+	}
+	return n, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+	// lastChance allows last_chance.
+	lastChance bool
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// accept consumes the next token if it is one of ops, returning it in its
+// canonical form.
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	for _, op := range ops {
+		if t == op {
+			p.pos++
+			switch t {
+			case "&&":
+				return "and", true
+			case "||":
+				return "or", true
+			case "!":
+				return "not", true
+			}
+			return t, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) or() (node, error) {
+	return p.logical(p.and, "or", "||")
+}
+
+func (p *parser) and() (node, error) {
+	return p.logical(p.not, "and", "&&")
+}
+
+func (p *parser) logical(operand func() (node, error), ops ...string) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if x.kind() != kindBool || y.kind() != kindBool {
+			return nil, fmt.Errorf("%s needs conditions on both sides", op)
+		}
+		x = binary{op: op, x: x, y: y}
+	}
+}
+
+func (p *parser) not() (node, error) {
+	if _, ok := p.accept("not", "!"); ok {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		if x.kind() != kindBool {
+			return nil, fmt.Errorf("not needs a condition")
+		}
+		return notNode{x}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	x, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return x, nil
+	}
+	y, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "==", "!=":
+		if x.kind() != y.kind() {
+			return nil, fmt.Errorf("cannot compare %s with %s", x.kind(), y.kind())
+		}
+	default:
+		if x.kind() != kindNumber || y.kind() != kindNumber {
+			return nil, fmt.Errorf("%s needs numbers on both sides", op)
+		}
+	}
+	return binary{op: op, x: x, y: y}, nil
+}
+
+func (p *parser) sum() (node, error) {
+	return p.arithmetic(p.term, "+", "-")
+}
+
+func (p *parser) term() (node, error) {
+	return p.arithmetic(p.unary, "*", "/")
+}
+
+func (p *parser) arithmetic(operand func() (node, error), ops ...string) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if x.kind() != kindNumber || y.kind() != kindNumber {
+			return nil, fmt.Errorf("%s needs numbers on both sides", op)
+		}
+		x = binary{op: op, x: x, y: y}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if _, ok := p.accept("-"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if x.kind() != kindNumber {
+			return nil, fmt.Errorf("- needs a number")
+		}
+		return negNode{x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case t == "(":
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return x, nil
+	case t == "true" || t == "false":
+		return boolLit(t == "true"), nil
+	case t[0] == '"':
+		return textLit(t[1 : len(t)-1]), nil
+	case t[0] == '$':
+		if len(t) == 1 {
+			return nil, fmt.Errorf("missing threshold name after $")
+		}
+		return thresholdRef(t[1:]), nil
+	case unicode.IsDigit(rune(t[0])) || t[0] == '.':
+		n, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t)
+		}
+		return numberLit(n), nil
+	case t == "last_chance" && p.lastChance:
+		return lastChanceRef{}, nil
+	case isIdentStart(rune(t[0])):
+		f, ok := fields[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", t)
+		}
+		return fieldRef{f}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdent(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
+
+func lex(src string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(src); {
+		r := rune(src[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, src[i:i+end+2])
+			i += end + 2
+		case r == '$' || isIdentStart(r):
+			j := i + 1
+			for j < len(src) && isIdent(rune(src[j])) {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		case unicode.IsDigit(r) || r == '.':
+			j := i + 1
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		default:
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					tokens = append(tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("()<>!+-*/", r) {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,88 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+func TestExpression(t *testing.T) {
+	hour := weather.Hour{
+		Time:         "2025-07-10 08:00",
+		ChanceOfRain: 70,
+		PrecipMM:     1.5,
+		GustKPH:      48,
+		WindDir:      "SW",
+		AirQuality:   &weather.AirQuality{USEPAIndex: 3},
+	}
+	thresholds := map[string]int{"drizzleThreshold": 50}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"chance_of_rain >= $drizzleThreshold", true},
+		{"chance_of_rain >= $drizzleThreshold and gust_kph > 50", false},
+		{"chance_of_rain > 80 || gust_kph >= 45", true},
+		{"not (precip_mm < 1)", true},
+		{"!(precip_mm < 1) && wind_dir == \"SW\"", true},
+		{"precipitation == \"rain\" and precipitation != \"snow\"", true},
+		{"hour >= 7 and hour <= 9", true},
+		{"us_epa_index >= 4", false},
+		{"gust_kph / 2 + -1 == 23", true},
+		{"chance_of_rain * precip_mm > 100", true},
+		{"true and not false", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			n, err := parse(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			v, err := n.eval(env{hour: hour, thresholds: thresholds})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.b != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, v.b)
+			}
+		})
+	}
+
+	t.Run("Missing threshold", func(t *testing.T) {
+		n, _ := parse("gust_kph > $gustThreshold")
+		if _, err := n.eval(env{hour: hour}); err == nil || !strings.Contains(err.Error(), "gustThreshold") {
+			t.Errorf("expected a missing threshold error, got %v", err)
+		}
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"", "unexpected end"},
+		{"chance_of_rain", "not a condition"},
+		{"chance_of_hail > 10", "unknown field"},
+		{"wind_dir > 10", "needs numbers"},
+		{"wind_dir == 10", "cannot compare"},
+		{"gust_kph > 10 and 5", "needs conditions"},
+		{"(gust_kph > 10", "missing )"},
+		{"gust_kph > 10 gust_kph", "unexpected"},
+		{"gust_kph > 10 % 3", "unexpected character"},
+		{"wind_dir == \"SW", "unterminated string"},
+		{"gust_kph > $", "missing threshold name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parse(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
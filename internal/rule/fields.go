@@ -0,0 +1,74 @@
+package rule
+
+import (
+	"strconv"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+type field struct {
+	kind kind
+	get  func(h weather.Hour) value
+}
+
+type env struct {
+	hour       weather.Hour
+	thresholds map[string]int
+	// lastChance is the chance of precipitation when the alert was last
+	// sent, for repeat conditions.
+	lastChance int
+}
+
+func number(get func(h weather.Hour) float64) field {
+	return field{kind: kindNumber, get: func(h weather.Hour) value { return value{num: get(h)} }}
+}
+
+func text(get func(h weather.Hour) string) field {
+	return field{kind: kindText, get: func(h weather.Hour) value { return value{text: get(h)} }}
+}
+
+// airQuality reads an air quality field, 0 when it was not requested.
+func airQuality(get func(a weather.AirQuality) float64) field {
+	return number(func(h weather.Hour) float64 {
+		if h.AirQuality == nil {
+			return 0
+		}
+		return get(*h.AirQuality)
+	})
+}
+
+// fields are the forecast fields rules can use, named as in the WeatherAPI
+// response.
+var fields = map[string]field{
+	"hour": number(func(h weather.Hour) float64 {
+		// Time is "2006-01-02 15:04".
+		if len(h.Time) < 13 {
+			return 0
+		}
+		n, _ := strconv.Atoi(h.Time[11:13])
+		return float64(n)
+	}),
+	"is_day":         number(func(h weather.Hour) float64 { return float64(h.IsDay) }),
+	"chance":         number(func(h weather.Hour) float64 { return float64(h.Chance()) }),
+	"chance_of_rain": number(func(h weather.Hour) float64 { return float64(h.ChanceOfRain) }),
+	"chance_of_snow": number(func(h weather.Hour) float64 { return float64(h.ChanceOfSnow) }),
+	"will_it_rain":   number(func(h weather.Hour) float64 { return float64(h.WillItRain) }),
+	"will_it_snow":   number(func(h weather.Hour) float64 { return float64(h.WillItSnow) }),
+	"precip_mm":      number(func(h weather.Hour) float64 { return h.PrecipMM }),
+	"snow_cm":        number(func(h weather.Hour) float64 { return h.SnowCM }),
+	"temp_c":         number(func(h weather.Hour) float64 { return h.TempC }),
+	"feelslike_c":    number(func(h weather.Hour) float64 { return h.FeelsLikeC }),
+	"heatindex_c":    number(func(h weather.Hour) float64 { return h.HeatIndexC }),
+	"uv":             number(func(h weather.Hour) float64 { return h.UV }),
+	"wind_kph":       number(func(h weather.Hour) float64 { return h.WindKPH }),
+	"gust_kph":       number(func(h weather.Hour) float64 { return h.GustKPH }),
+	"condition_code": number(func(h weather.Hour) float64 { return float64(h.Condition.Code) }),
+	"us_epa_index":   airQuality(func(a weather.AirQuality) float64 { return float64(a.USEPAIndex) }),
+	"pm2_5":          airQuality(func(a weather.AirQuality) float64 { return a.PM25 }),
+	"pm10":           airQuality(func(a weather.AirQuality) float64 { return a.PM10 }),
+	"o3":             airQuality(func(a weather.AirQuality) float64 { return a.O3 }),
+
+	"precipitation": text(func(h weather.Hour) string { return string(h.Precipitation()) }),
+	"condition":     text(func(h weather.Hour) string { return h.Condition.Text }),
+	"wind_dir":      text(func(h weather.Hour) string { return h.WindDir }),
+}
@@ -0,0 +1,238 @@
+// Package rule evaluates user-defined alert conditions over the next hour's
+// forecast.
+package rule
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/message"
+	"github.com/imedgar/rain-alert/internal/weather"
+	"gopkg.in/yaml.v3"
+)
+
+// Rain names the rule that, when defined, replaces the conditions of the
+// built-in rain alert. Its severity is the least the alert is sent with, and
+// its message, when set, replaces the rain templates.
+const Rain = "rain"
+
+// DefaultRain is the condition of the built-in rain alert.
+const DefaultRain = "chance >= $drizzleThreshold"
+
+// DefaultRainRepeat sends the built-in rain alert again within its cooldown
+// unless the last one was already likely rain. It applies to a rain rule
+// without a repeat condition too.
+const DefaultRainRepeat = "last_chance <= $rainBeforeThreshold"
+
+// DefaultRainExit ends the built-in rain alert when drizzleExitThreshold is
+// configured, making it a hysteresis alert.
+const DefaultRainExit = "chance < $drizzleExitThreshold"
+
+// DefaultCooldown applies to rules without a cooldown.
+const DefaultCooldown = time.Hour
+
+// Severities a rule can have, from lowest to highest.
+var Severities = []string{"low", "moderate", "high"}
+
+// Definition is a rule as written in a rules file or the alert_rules table.
+type Definition struct {
+	Name string `yaml:"name"`
+	When string `yaml:"when"`
+	// Exit, when set, is the condition ending the alert. The alert is then
+	// sent once when When becomes true, and not again until Exit has been
+	// true, instead of after each cooldown.
+	Exit string `yaml:"exit"`
+	// Repeat, when set, is the condition under which the alert is sent again
+	// within its cooldown. It can refer to the chance of precipitation when
+	// the alert was last sent as last_chance.
+	Repeat   string `yaml:"repeat"`
+	Severity string `yaml:"severity"`
+	// Cooldown is a duration such as "3h".
+	Cooldown string `yaml:"cooldown"`
+	Title    string `yaml:"title"`
+	// Message is a text/template executed with the location name (.Name) and
+	// the forecast hour (.Hour), as the built-in reports are.
+	Message string `yaml:"message"`
+}
+
+// Rule is a compiled, validated definition.
+type Rule struct {
+	Name     string
+	When     string
+	Exit     string
+	Repeat   string
+	Severity string
+	Cooldown time.Duration
+	Title    string
+	Message  string
+	// Thresholds are the names of the thresholds the conditions refer to.
+	Thresholds []string
+
+	expr   node
+	exit   node
+	repeat node
+}
+
+// Compile validates definitions: names must be unique, conditions must
+// parse, type check and only refer to configured thresholds, and messages
+// must parse.
+func Compile(defs []Definition, thresholds map[string]int) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(defs))
+	seen := map[string]bool{}
+	for i, d := range defs {
+		if d.Name == "" {
+			return nil, fmt.Errorf("rule %d: name is required", i)
+		}
+		if seen[d.Name] {
+			return nil, fmt.Errorf("rule %s: duplicate name", d.Name)
+		}
+		seen[d.Name] = true
+
+		r, err := compile(d)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", d.Name, err)
+		}
+		for _, name := range r.Thresholds {
+			if _, ok := thresholds[name]; !ok {
+				return nil, fmt.Errorf("rule %s: threshold %q not configured", d.Name, name)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// MustCompile compiles a single definition, panicking if it is invalid.
+func MustCompile(d Definition) *Rule {
+	r, err := compile(d)
+	if err != nil {
+		panic(fmt.Sprintf("rule %s: %v", d.Name, err))
+	}
+	return r
+}
+
+func compile(d Definition) (*Rule, error) {
+	expr, err := parse(d.When)
+	if err != nil {
+		return nil, fmt.Errorf("when: %w", err)
+	}
+
+	r := &Rule{
+		Name:     d.Name,
+		When:     d.When,
+		Severity: cmp.Or(d.Severity, Severities[0]),
+		Cooldown: DefaultCooldown,
+		Title:    cmp.Or(d.Title, d.Name),
+		Message:  d.Message,
+		expr:     expr,
+	}
+
+	if d.Exit != "" {
+		if r.exit, err = parse(d.Exit); err != nil {
+			return nil, fmt.Errorf("exit: %w", err)
+		}
+		r.Exit = d.Exit
+	}
+
+	if d.Name == Rain {
+		// The rain alert keeps its localized title unless one is set.
+		r.Title = d.Title
+		r.Repeat = cmp.Or(d.Repeat, DefaultRainRepeat)
+	} else {
+		r.Repeat = d.Repeat
+	}
+	if r.Repeat != "" {
+		if r.repeat, err = parseRepeat(r.Repeat); err != nil {
+			return nil, fmt.Errorf("repeat: %w", err)
+		}
+	}
+	r.Thresholds = thresholdNames(r.expr, r.exit, r.repeat)
+
+	if !slices.Contains(Severities, r.Severity) {
+		return nil, fmt.Errorf("unknown severity %q", d.Severity)
+	}
+	if d.Cooldown != "" {
+		if r.Cooldown, err = time.ParseDuration(d.Cooldown); err != nil || r.Cooldown < 0 {
+			return nil, fmt.Errorf("invalid cooldown %q", d.Cooldown)
+		}
+	}
+	if d.Name == Rain && d.Message == "" {
+		return r, nil
+	}
+	if d.Message == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+	if err := message.CheckTemplate(d.Message); err != nil {
+		return nil, fmt.Errorf("message: %w", err)
+	}
+	return r, nil
+}
+
+// Match evaluates the rule's condition for a forecast hour. Thresholds are
+// referenced as $name.
+func (r *Rule) Match(h weather.Hour, thresholds map[string]int) (bool, error) {
+	v, err := r.expr.eval(env{hour: h, thresholds: thresholds})
+	if err != nil {
+		return false, fmt.Errorf("rule %s: %w", r.Name, err)
+	}
+	return v.b, nil
+}
+
+// Hysteresis reports whether the rule has an exit condition.
+func (r *Rule) Hysteresis() bool {
+	return r.exit != nil
+}
+
+// Exits evaluates the rule's exit condition for a forecast hour. Rules
+// without one exit whenever they do not match.
+func (r *Rule) Exits(h weather.Hour, thresholds map[string]int) (bool, error) {
+	if r.exit == nil {
+		match, err := r.Match(h, thresholds)
+		return !match, err
+	}
+	v, err := r.exit.eval(env{hour: h, thresholds: thresholds})
+	if err != nil {
+		return false, fmt.Errorf("rule %s exit: %w", r.Name, err)
+	}
+	return v.b, nil
+}
+
+// Repeats evaluates the rule's repeat condition for a forecast hour, given
+// the chance of precipitation when the alert was last sent. Rules without
+// one are not sent again within their cooldown.
+func (r *Rule) Repeats(h weather.Hour, thresholds map[string]int, lastChance int) (bool, error) {
+	if r.repeat == nil {
+		return false, nil
+	}
+	v, err := r.repeat.eval(env{hour: h, thresholds: thresholds, lastChance: lastChance})
+	if err != nil {
+		return false, fmt.Errorf("rule %s repeat: %w", r.Name, err)
+	}
+	return v.b, nil
+}
+
+// LoadFile reads definitions from a YAML file holding a list of rules.
+func LoadFile(path string) ([]Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules file: %w", err)
+	}
+
+	var defs []Definition
+	if err := yaml.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("decoding rules file: %w", err)
+	}
+	return defs, nil
+}
+
+// Find returns the rule with the given name, if any.
+func Find(rules []*Rule, name string) (*Rule, bool) {
+	i := slices.IndexFunc(rules, func(r *Rule) bool { return r.Name == name })
+	if i < 0 {
+		return nil, false
+	}
+	return rules[i], true
+}
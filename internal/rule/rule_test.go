@@ -0,0 +1,144 @@
+package rule
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imedgar/rain-alert/internal/weather"
+)
+
+func TestCompile(t *testing.T) {
+	configured := map[string]int{"drizzleThreshold": 50, "drizzleExitThreshold": 40, "rainBeforeThreshold": 70}
+
+	t.Run("Valid", func(t *testing.T) {
+		rules, err := Compile([]Definition{
+			{Name: "windy-ride", When: "gust_kph >= 45", Severity: "moderate", Cooldown: "3h", Message: "Gusts in {{.Name}}"},
+			{Name: Rain, When: "chance >= 60"},
+		}, configured)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		r := rules[0]
+		if r.Severity != "moderate" || r.Cooldown != 3*time.Hour || r.Title != "windy-ride" {
+			t.Errorf("unexpected rule: %+v", r)
+		}
+		if rain, ok := Find(rules, Rain); !ok || rain.Cooldown != DefaultCooldown || rain.Severity != "low" {
+			t.Errorf("unexpected rain rule: %+v", rain)
+		}
+
+		match, err := r.Match(weather.Hour{GustKPH: 50}, nil)
+		if err != nil || !match {
+			t.Errorf("expected a match, got %t, %v", match, err)
+		}
+	})
+
+	t.Run("Exit", func(t *testing.T) {
+		rules, err := Compile([]Definition{{Name: Rain, When: "chance >= $drizzleThreshold", Exit: "chance < $drizzleExitThreshold"}}, configured)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		r := rules[0]
+		if !r.Hysteresis() {
+			t.Error("expected a hysteresis rule")
+		}
+		if !slices.Equal(r.Thresholds, []string{"drizzleExitThreshold", "drizzleThreshold", "rainBeforeThreshold"}) {
+			t.Errorf("unexpected thresholds %v", r.Thresholds)
+		}
+		thresholds := map[string]int{"drizzleThreshold": 50, "drizzleExitThreshold": 40}
+		for chance, expected := range map[int]bool{35: true, 45: false, 55: false} {
+			exit, err := r.Exits(weather.Hour{ChanceOfRain: chance}, thresholds)
+			if err != nil || exit != expected {
+				t.Errorf("expected exit %t at %d%%, got %t, %v", expected, chance, exit, err)
+			}
+		}
+	})
+
+	t.Run("Repeat", func(t *testing.T) {
+		rules, err := Compile([]Definition{
+			{Name: "windy-ride", When: "gust_kph >= 45", Message: "Gusts in {{.Name}}"},
+			{Name: "storm", When: "gust_kph >= 45", Repeat: "gust_kph >= 80 and last_chance < chance", Message: "Storm in {{.Name}}"},
+			{Name: Rain, When: "chance >= 60"},
+		}, configured)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		hour := weather.Hour{GustKPH: 90, ChanceOfRain: 70}
+		for _, tt := range []struct {
+			rule       string
+			lastChance int
+			expected   bool
+		}{
+			{"windy-ride", 0, false},
+			{"storm", 50, true},
+			{"storm", 70, false},
+			{Rain, 70, true},
+			{Rain, 80, false},
+		} {
+			r, _ := Find(rules, tt.rule)
+			repeat, err := r.Repeats(hour, configured, tt.lastChance)
+			if err != nil || repeat != tt.expected {
+				t.Errorf("%s: expected repeat %t after %d%%, got %t, %v", tt.rule, tt.expected, tt.lastChance, repeat, err)
+			}
+		}
+	})
+
+	tests := []struct {
+		name string
+		defs []Definition
+		err  string
+	}{
+		{"No name", []Definition{{When: "uv > 8", Message: "UV"}}, "name is required"},
+		{"Duplicate", []Definition{{Name: "uv", When: "uv > 8", Message: "UV"}, {Name: "uv", When: "uv > 9", Message: "UV"}}, "duplicate name"},
+		{"Bad condition", []Definition{{Name: "uv", When: "uv >", Message: "UV"}}, "rule uv: when"},
+		{"Bad exit", []Definition{{Name: "uv", When: "uv > 8", Exit: "uv", Message: "UV"}}, "rule uv: exit"},
+		{"Bad severity", []Definition{{Name: "uv", When: "uv > 8", Severity: "extreme", Message: "UV"}}, "unknown severity"},
+		{"Bad cooldown", []Definition{{Name: "uv", When: "uv > 8", Cooldown: "soon", Message: "UV"}}, "invalid cooldown"},
+		{"No message", []Definition{{Name: "uv", When: "uv > 8"}}, "message is required"},
+		{"Bad message", []Definition{{Name: "uv", When: "uv > 8", Message: "{{.Hour"}}, "rule uv: message"},
+		{"Unknown threshold", []Definition{{Name: "uv", When: "uv > $uvThreshold", Message: "UV"}}, `threshold "uvThreshold" not configured`},
+		{"Unknown exit threshold", []Definition{{Name: Rain, When: "chance > 50", Exit: "chance < $rainExit"}}, `rule rain: threshold "rainExit"`},
+		{"Bad repeat", []Definition{{Name: "uv", When: "uv > 8", Repeat: "uv +", Message: "UV"}}, "rule uv: repeat"},
+		{"Last chance outside repeat", []Definition{{Name: "uv", When: "last_chance > 8", Message: "UV"}}, `unknown field "last_chance"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.defs, configured)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `
+- name: windy-ride
+  when: gust_kph >= 45 and is_day == 1
+  severity: moderate
+  cooldown: 3h
+  title: Windy ride
+  message: "Gusts up to {{kph .Hour.GustKPH}} km/h in {{.Name}}."
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	defs, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(defs) != 1 || defs[0].Name != "windy-ride" || defs[0].Cooldown != "3h" || defs[0].Title != "Windy ride" {
+		t.Errorf("unexpected definitions: %+v", defs)
+	}
+	if _, err := Compile(defs, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
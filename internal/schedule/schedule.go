@@ -0,0 +1,185 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Days is a set of weekdays. The zero value means every day.
+type Days uint8
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+const (
+	Weekdays = Days(1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday)
+	Weekends = Days(1<<time.Saturday | 1<<time.Sunday)
+)
+
+// ParseDays parses "", "daily", "weekdays", "weekends", a comma separated
+// list such as "mon,wed,fri" or a range such as "mon-fri".
+func ParseDays(s string) (Days, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "daily":
+		return 0, nil
+	case "weekdays":
+		return Weekdays, nil
+	case "weekends":
+		return Weekends, nil
+	}
+
+	var d Days
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if from, to, ok := strings.Cut(part, "-"); ok {
+			start, okStart := dayNames[from]
+			end, okEnd := dayNames[to]
+			if !okStart || !okEnd {
+				return 0, fmt.Errorf("invalid day range %q", part)
+			}
+			for wd := start; ; wd = (wd + 1) % 7 {
+				d |= 1 << wd
+				if wd == end {
+					break
+				}
+			}
+			continue
+		}
+		wd, ok := dayNames[part]
+		if !ok {
+			return 0, fmt.Errorf("invalid day %q", part)
+		}
+		d |= 1 << wd
+	}
+	return d, nil
+}
+
+// Has reports whether the weekday is in the set.
+func (d Days) Has(wd time.Weekday) bool {
+	return d == 0 || d&(1<<wd) != 0
+}
+
+func (d *Days) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseDays(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Window is a recurring local time span on some days of the week. When End
+// is before Start the window wraps past midnight and belongs to the day it
+// starts on.
+type Window struct {
+	Days  Days   `json:"days"`
+	Start string `json:"start"` // HH:MM
+	End   string `json:"end"`   // HH:MM
+}
+
+// Validate checks that Start and End are valid clock times.
+func (w Window) Validate() error {
+	start, err := MinuteOfDay(w.Start)
+	if err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	end, err := MinuteOfDay(w.End)
+	if err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	if start == end {
+		return fmt.Errorf("start and end are both %s", w.Start)
+	}
+	return nil
+}
+
+// Contains reports whether t, in its own location, falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	start, errStart := MinuteOfDay(w.Start)
+	end, errEnd := MinuteOfDay(w.End)
+	if errStart != nil || errEnd != nil || start == end {
+		return false
+	}
+
+	m := t.Hour()*60 + t.Minute()
+	if start < end {
+		return m >= start && m < end && w.Days.Has(t.Weekday())
+	}
+	if m >= start {
+		return w.Days.Has(t.Weekday())
+	}
+	return m < end && w.Days.Has(t.AddDate(0, 0, -1).Weekday())
+}
+
+// Occurrence returns the start and end of the window's occurrence that
+// starts on the same calendar day as t, and whether the window runs that day.
+func (w Window) Occurrence(t time.Time) (start, end time.Time, ok bool) {
+	s, errStart := MinuteOfDay(w.Start)
+	e, errEnd := MinuteOfDay(w.End)
+	if errStart != nil || errEnd != nil || !w.Days.Has(t.Weekday()) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	start = midnight.Add(time.Duration(s) * time.Minute)
+	end = midnight.Add(time.Duration(e) * time.Minute)
+	if e <= s {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, true
+}
+
+// NextStart returns the start and end of the first occurrence of the window
+// starting at or after t, looking up to a week ahead.
+func (w Window) NextStart(t time.Time) (start, end time.Time, ok bool) {
+	for i := range 8 {
+		day := t.AddDate(0, 0, i)
+		if start, end, ok := w.Occurrence(day); ok && !start.Before(t) {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// Duration is a time.Duration decoded from strings such as "45m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MinuteOfDay parses "HH:MM" into minutes since midnight.
+func MinuteOfDay(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	hour, err := strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("invalid hour in %q", s)
+	}
+	minute, err := strconv.Atoi(m)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid minute in %q", s)
+	}
+	return hour*60 + minute, nil
+}
@@ -0,0 +1,126 @@
+package schedule
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Days
+	}{
+		{"", 0},
+		{"daily", 0},
+		{"weekdays", Weekdays},
+		{"Weekends", Weekends},
+		{"mon-fri", Weekdays},
+		{"sat,sun", Weekends},
+		{"fri-mon", Weekends | 1<<time.Friday | 1<<time.Monday},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d, err := ParseDays(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d != tt.expected {
+				t.Errorf("expected %07b, got %07b", tt.expected, d)
+			}
+		})
+	}
+
+	if _, err := ParseDays("someday"); err == nil {
+		t.Error("expected an error, but got nil")
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	// 2025-07-11 is a Friday.
+	at := func(day, h, m int) time.Time { return time.Date(2025, 7, day, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		window   Window
+		now      time.Time
+		expected bool
+	}{
+		{"Same day inside", Window{Start: "12:00", End: "14:00"}, at(11, 13, 0), true},
+		{"Same day end excluded", Window{Start: "12:00", End: "14:00"}, at(11, 14, 0), false},
+		{"Weekdays on Friday", Window{Days: Weekdays, Start: "08:15", End: "08:45"}, at(11, 8, 30), true},
+		{"Weekdays on Saturday", Window{Days: Weekdays, Start: "08:15", End: "08:45"}, at(12, 8, 30), false},
+		{"Overnight late", Window{Start: "22:00", End: "07:00"}, at(11, 23, 30), true},
+		{"Overnight early", Window{Start: "22:00", End: "07:00"}, at(11, 6, 59), true},
+		{"Overnight outside", Window{Start: "22:00", End: "07:00"}, at(11, 7, 0), false},
+		// Friday night belongs to the weekdays rule, Saturday morning too.
+		{"Weekday night into Saturday", Window{Days: Weekdays, Start: "22:00", End: "07:00"}, at(12, 6, 0), true},
+		{"Weekday night into Monday", Window{Days: Weekdays, Start: "22:00", End: "07:00"}, at(14, 6, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWindowJSON(t *testing.T) {
+	var w Window
+	if err := json.Unmarshal([]byte(`{"days": "weekends", "start": "22:00", "end": "09:00"}`), &w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Days != Weekends || w.Validate() != nil {
+		t.Errorf("unexpected window %+v", w)
+	}
+
+	if err := (Window{Start: "8:00", End: "25:00"}).Validate(); err == nil {
+		t.Error("expected an error, but got nil")
+	}
+}
+
+func TestWindowNextStart(t *testing.T) {
+	commute := Window{Days: Weekdays, Start: "08:15", End: "08:45"}
+
+	t.Run("Later today", func(t *testing.T) {
+		// Friday 07:30.
+		start, end, ok := commute.NextStart(time.Date(2025, 7, 11, 7, 30, 0, 0, time.UTC))
+		if !ok {
+			t.Fatal("expected an occurrence")
+		}
+		if !start.Equal(time.Date(2025, 7, 11, 8, 15, 0, 0, time.UTC)) || end.Sub(start) != 30*time.Minute {
+			t.Errorf("unexpected occurrence %s-%s", start, end)
+		}
+	})
+
+	t.Run("Skips the weekend", func(t *testing.T) {
+		// Friday 09:00, next is Monday.
+		start, _, ok := commute.NextStart(time.Date(2025, 7, 11, 9, 0, 0, 0, time.UTC))
+		if !ok || !start.Equal(time.Date(2025, 7, 14, 8, 15, 0, 0, time.UTC)) {
+			t.Errorf("expected Monday 08:15, got %s", start)
+		}
+	})
+
+	t.Run("Overnight end", func(t *testing.T) {
+		_, end, _ := Window{Start: "23:30", End: "00:30"}.NextStart(time.Date(2025, 7, 11, 9, 0, 0, 0, time.UTC))
+		if !end.Equal(time.Date(2025, 7, 12, 0, 30, 0, 0, time.UTC)) {
+			t.Errorf("expected end after midnight, got %s", end)
+		}
+	})
+}
+
+func TestDurationJSON(t *testing.T) {
+	var d Duration
+	if err := json.Unmarshal([]byte(`"45m"`), &d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Duration(d) != 45*time.Minute {
+		t.Errorf("expected 45m, got %s", time.Duration(d))
+	}
+	if err := json.Unmarshal([]byte(`"soon"`), &d); err == nil {
+		t.Error("expected an error, but got nil")
+	}
+}
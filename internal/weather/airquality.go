@@ -0,0 +1,23 @@
+package weather
+
+// AirQuality of a forecast hour. Concentrations are in µg/m³.
+type AirQuality struct {
+	CO           float64 `json:"co"`
+	NO2          float64 `json:"no2"`
+	O3           float64 `json:"o3"`
+	SO2          float64 `json:"so2"`
+	PM25         float64 `json:"pm2_5"`
+	PM10         float64 `json:"pm10"`
+	USEPAIndex   int     `json:"us-epa-index"`
+	GBDefraIndex int     `json:"gb-defra-index"`
+}
+
+// US EPA index levels.
+const (
+	AQIGood = iota + 1
+	AQIModerate
+	AQIUnhealthyForSensitive
+	AQIUnhealthy
+	AQIVeryUnhealthy
+	AQIHazardous
+)
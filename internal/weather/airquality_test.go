@@ -0,0 +1,38 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetForecastAirQuality(t *testing.T) {
+	body := `{"forecast": {"forecastday": [{"date": "2025-07-10", "hour": [` +
+		`{"time": "2025-07-10 00:00", "air_quality": {"o3": 120.5, "pm2_5": 38.2, "pm10": 51, "us-epa-index": 4}}` +
+		`]}]}}`
+
+	var aqi string
+	mockClient := NewMockClient(http.StatusOK, body)
+	doFunc := mockClient.DoFunc
+	mockClient.DoFunc = func(req *http.Request) (*http.Response, error) {
+		aqi = req.URL.Query().Get("aqi")
+		return doFunc(req)
+	}
+	api := NewAPI(mockClient, "http://test.com", "test-key")
+
+	weather, err := api.fetchWeather(context.Background(), "Test Location", 1, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aqi != "yes" {
+		t.Errorf("expected air quality to be requested, got %q", aqi)
+	}
+
+	air := weather.Forecast.ForecastDay[0].Hour[0].AirQuality
+	if air == nil {
+		t.Fatal("expected air quality")
+	}
+	if air.USEPAIndex != AQIUnhealthy || air.PM25 != 38.2 || air.PM10 != 51 || air.O3 != 120.5 {
+		t.Errorf("unexpected air quality: %+v", *air)
+	}
+}
@@ -0,0 +1,52 @@
+package weather
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Alert is a government weather warning, such as a storm or flood warning.
+type Alert struct {
+	Headline    string `json:"headline"`
+	MsgType     string `json:"msgtype"`
+	Severity    string `json:"severity"`
+	Urgency     string `json:"urgency"`
+	Areas       string `json:"areas"`
+	Category    string `json:"category"`
+	Certainty   string `json:"certainty"`
+	Event       string `json:"event"`
+	Note        string `json:"note"`
+	Effective   string `json:"effective"`
+	Expires     string `json:"expires"`
+	Desc        string `json:"desc"`
+	Instruction string `json:"instruction"`
+}
+
+// ID identifies the warning across updates. WeatherAPI gives alerts no
+// identifier, so it is derived from the event and the areas: updates usually
+// move the effective time, so it is left out.
+func (a Alert) ID() string {
+	return hash(a.Event, a.Areas)
+}
+
+// Version changes whenever the content of the warning does.
+func (a Alert) Version() string {
+	return hash(a.MsgType, a.Headline, a.Severity, a.Effective, a.Expires, a.Desc, a.Instruction)
+}
+
+// Expired reports whether the alert has expired at t. Alerts without a valid
+// expiry never do.
+func (a Alert) Expired(t time.Time) bool {
+	expires, err := time.Parse(time.RFC3339, a.Expires)
+	if err != nil {
+		return false
+	}
+	return !t.Before(expires)
+}
+
+func hash(fields ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,66 @@
+package weather
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAlerts(t *testing.T) {
+	body := `{"alerts": {"alert": [{
+		"headline": "Orange thunderstorm warning",
+		"msgtype": "Alert",
+		"severity": "Moderate",
+		"areas": "Madrid",
+		"event": "Thunderstorms",
+		"effective": "2025-07-10T14:00:00+02:00",
+		"expires": "2025-07-10T22:00:00+02:00",
+		"desc": "Storms with heavy rain and hail."
+	}]}}`
+
+	var weather WeatherResponse
+	if err := json.Unmarshal([]byte(body), &weather); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(weather.Alerts.Alert) != 1 {
+		t.Fatalf("expected one alert, got %d", len(weather.Alerts.Alert))
+	}
+	alert := weather.Alerts.Alert[0]
+
+	t.Run("Update keeps the ID", func(t *testing.T) {
+		update := alert
+		update.MsgType = "Update"
+		update.Effective = "2025-07-10T16:00:00+02:00"
+		update.Expires = "2025-07-11T02:00:00+02:00"
+
+		if update.ID() != alert.ID() {
+			t.Error("expected the same ID for an update")
+		}
+		if update.Version() == alert.Version() {
+			t.Error("expected a new version for an update")
+		}
+	})
+
+	t.Run("Another event has another ID", func(t *testing.T) {
+		other := alert
+		other.Event = "Floods"
+
+		if other.ID() == alert.ID() {
+			t.Error("expected a different ID")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expires, _ := time.Parse(time.RFC3339, alert.Expires)
+
+		if alert.Expired(expires.Add(-time.Minute)) {
+			t.Error("expected the alert to be active before it expires")
+		}
+		if !alert.Expired(expires) {
+			t.Error("expected the alert to be expired")
+		}
+		if (Alert{}).Expired(expires) {
+			t.Error("expected an alert without expiry never to expire")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package weather
+
+// Precipitation is the type of precipitation of a forecast hour.
+type Precipitation string
+
+const (
+	PrecipitationRain         Precipitation = "rain"
+	PrecipitationSnow         Precipitation = "snow"
+	PrecipitationSleet        Precipitation = "sleet"
+	PrecipitationThunder      Precipitation = "thunder"
+	PrecipitationFreezingRain Precipitation = "freezing-rain"
+)
+
+// conditions maps WeatherAPI condition codes to the precipitation they
+// describe. See https://www.weatherapi.com/docs/weather_conditions.json.
+var conditions = map[int]Precipitation{
+	1063: PrecipitationRain, 1150: PrecipitationRain, 1153: PrecipitationRain,
+	1180: PrecipitationRain, 1183: PrecipitationRain, 1186: PrecipitationRain,
+	1189: PrecipitationRain, 1192: PrecipitationRain, 1195: PrecipitationRain,
+	1240: PrecipitationRain, 1243: PrecipitationRain, 1246: PrecipitationRain,
+
+	1066: PrecipitationSnow, 1114: PrecipitationSnow, 1117: PrecipitationSnow,
+	1210: PrecipitationSnow, 1213: PrecipitationSnow, 1216: PrecipitationSnow,
+	1219: PrecipitationSnow, 1222: PrecipitationSnow, 1225: PrecipitationSnow,
+	1255: PrecipitationSnow, 1258: PrecipitationSnow,
+
+	1069: PrecipitationSleet, 1204: PrecipitationSleet, 1207: PrecipitationSleet,
+	1237: PrecipitationSleet, 1249: PrecipitationSleet, 1252: PrecipitationSleet,
+	1261: PrecipitationSleet, 1264: PrecipitationSleet,
+
+	1072: PrecipitationFreezingRain, 1168: PrecipitationFreezingRain, 1171: PrecipitationFreezingRain,
+	1198: PrecipitationFreezingRain, 1201: PrecipitationFreezingRain,
+
+	1087: PrecipitationThunder, 1273: PrecipitationThunder, 1276: PrecipitationThunder,
+	1279: PrecipitationThunder, 1282: PrecipitationThunder,
+}
+
+// Chance is the chance of any precipitation, rain or snow.
+func (h Hour) Chance() int {
+	return max(h.ChanceOfRain, h.ChanceOfSnow)
+}
+
+// Precipitation classifies the hour from its condition code. Without a
+// precipitation code it falls back to the chances of rain and snow, snow
+// winning ties as below freezing both are usually given the same chance.
+// Freezing rain, sent as urgent, is only reported from its condition codes.
+func (h Hour) Precipitation() Precipitation {
+	if p, ok := conditions[h.Condition.Code]; ok {
+		return p
+	}
+
+	if h.WillItSnow == 1 || (h.ChanceOfSnow > 0 && h.ChanceOfSnow >= h.ChanceOfRain) {
+		return PrecipitationSnow
+	}
+	return PrecipitationRain
+}
@@ -0,0 +1,42 @@
+package weather
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPrecipitation(t *testing.T) {
+	tests := []struct {
+		name     string
+		hour     string
+		expected Precipitation
+	}{
+		{"Rain code", `{"chance_of_rain": 80, "condition": {"code": 1189}}`, PrecipitationRain},
+		{"Snow code", `{"chance_of_snow": 70, "condition": {"code": 1219}}`, PrecipitationSnow},
+		{"Sleet code", `{"chance_of_rain": 60, "chance_of_snow": 60, "condition": {"code": 1249}}`, PrecipitationSleet},
+		{"Thunder code", `{"chance_of_rain": 60, "condition": {"code": 1276}}`, PrecipitationThunder},
+		{"Freezing drizzle code", `{"chance_of_rain": 60, "condition": {"code": 1072}}`, PrecipitationFreezingRain},
+		{"No code, snow likelier", `{"chance_of_rain": 20, "chance_of_snow": 70, "condition": {"code": 1003}}`, PrecipitationSnow},
+		{"No code, equal chances below freezing", `{"temp_c": -2.5, "chance_of_rain": 70, "chance_of_snow": 70, "condition": {"code": 1009}}`, PrecipitationSnow},
+		{"No code, below freezing", `{"temp_c": -2.5, "chance_of_rain": 70, "condition": {"code": 1030}}`, PrecipitationRain},
+		{"No code", `{"temp_c": 12, "chance_of_rain": 70}`, PrecipitationRain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hour Hour
+			if err := json.Unmarshal([]byte(tt.hour), &hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := hour.Precipitation(); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("Chance", func(t *testing.T) {
+		if chance := (Hour{ChanceOfRain: 30, ChanceOfSnow: 65}).Chance(); chance != 65 {
+			t.Errorf("expected 65, got %d", chance)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Place is a match of a location search.
+type Place struct {
+	ID      int64   `json:"id"`
+	Name    string  `json:"name"`
+	Region  string  `json:"region"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
+
+// String returns the canonical name of the place.
+func (p Place) String() string {
+	var parts []string
+	for _, part := range []string{p.Name, p.Region, p.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Search returns the places matching a name, postal code or coordinates,
+// best match first.
+func (a *API) Search(ctx context.Context, query string) ([]Place, error) {
+	if a.SearchURL == "" {
+		return nil, fmt.Errorf("no search URL")
+	}
+
+	params := url.Values{}
+	params.Set("key", a.ApiKey)
+	params.Set("q", query)
+
+	var places []Place
+	if err := a.get(ctx, a.SearchURL, params, &places); err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+// MatchPlaces returns the places in the given region and country, compared
+// case-insensitively. Empty region or country match any.
+func MatchPlaces(places []Place, region, country string) []Place {
+	var matches []Place
+	for _, p := range places {
+		if region != "" && !strings.EqualFold(p.Region, region) {
+			continue
+		}
+		if country != "" && !strings.EqualFold(p.Country, country) {
+			continue
+		}
+		matches = append(matches, p)
+	}
+	return matches
+}
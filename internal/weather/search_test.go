@@ -0,0 +1,75 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+const springfieldBody = `[
+	{"id": 1, "name": "Springfield", "region": "Missouri", "country": "United States of America", "lat": 37.22, "lon": -93.3, "url": "springfield-missouri"},
+	{"id": 2, "name": "Springfield", "region": "Illinois", "country": "United States of America", "lat": 39.8, "lon": -89.64, "url": "springfield-illinois"}
+]`
+
+func TestSearch(t *testing.T) {
+	t.Run("Successful search", func(t *testing.T) {
+		var query string
+		mockClient := &MockClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				query = req.URL.Query().Get("q")
+				return NewMockClient(http.StatusOK, springfieldBody).Do(req)
+			},
+		}
+		api := NewAPI(mockClient, "http://test.com", "test-key")
+		api.SearchURL = "http://test.com/search.json"
+
+		places, err := api.Search(context.Background(), "Springfield")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if query != "Springfield" {
+			t.Errorf("expected query 'Springfield', got '%s'", query)
+		}
+		if len(places) != 2 || places[1].Lat != 39.8 {
+			t.Errorf("unexpected places %+v", places)
+		}
+		if places[1].String() != "Springfield, Illinois, United States of America" {
+			t.Errorf("unexpected canonical name '%s'", places[1])
+		}
+	})
+
+	t.Run("Unexpected status", func(t *testing.T) {
+		api := NewAPI(NewMockClient(http.StatusBadRequest, ""), "http://test.com", "test-key")
+		api.SearchURL = "http://test.com/search.json"
+
+		if _, err := api.Search(context.Background(), "Springfield"); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+}
+
+func TestMatchPlaces(t *testing.T) {
+	places := []Place{
+		{ID: 1, Name: "Springfield", Region: "Missouri", Country: "United States of America"},
+		{ID: 2, Name: "Springfield", Region: "Illinois", Country: "United States of America"},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		region   string
+		country  string
+		expected int
+	}{
+		{"Any", "", "", 2},
+		{"Region", "illinois", "", 1},
+		{"Country", "", "United States of America", 2},
+		{"No match", "Oregon", "", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchPlaces(places, tt.region, tt.country); len(got) != tt.expected {
+				t.Errorf("expected %d places, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package weather
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Snapshot is a forecast as it was fetched at a point in time, to be
+// replayed.
+type Snapshot struct {
+	Time     time.Time
+	Forecast *WeatherResponse
+}
+
+// ParseSnapshot decodes a recorded WeatherResponse. Its time is when it was
+// issued, from its local time, unless at is set.
+func ParseSnapshot(body []byte, at time.Time) (Snapshot, error) {
+	var weather WeatherResponse
+	if err := json.Unmarshal(body, &weather); err != nil {
+		return Snapshot{}, fmt.Errorf("decoding forecast: %w", err)
+	}
+
+	if at.IsZero() {
+		var err error
+		if at, err = weather.IssuedAt(); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	return Snapshot{Time: at, Forecast: &weather}, nil
+}
+
+// IssuedAt is when the forecast was fetched, from localtime_epoch, or from
+// localtime in the location's timezone.
+func (w *WeatherResponse) IssuedAt() (time.Time, error) {
+	if w.Location.LocaltimeEpoch > 0 {
+		return time.Unix(w.Location.LocaltimeEpoch, 0), nil
+	}
+
+	tz, err := w.Timezone()
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.ParseInLocation(TimeLayout, w.Location.Localtime, tz)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid local time: %w", err)
+	}
+	return t, nil
+}
+
+// LoadSnapshots reads the recorded WeatherResponse JSON files in dir, oldest
+// first.
+func LoadSnapshots(dir string) ([]Snapshot, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(files))
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot: %w", err)
+		}
+		s, err := ParseSnapshot(body, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	slices.SortFunc(snapshots, func(a, b Snapshot) int { return a.Time.Compare(b.Time) })
+	return snapshots, nil
+}
@@ -0,0 +1,72 @@
+package weather
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b.json":    `{"location": {"tz_id": "Europe/Madrid", "localtime": "2025-07-10 16:05"}}`,
+		"a.json":    `{"location": {"localtime_epoch": 1752152700, "localtime": "2025-07-10 15:05"}}`,
+		"notes.txt": "ignored",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err := LoadSnapshots(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(snapshots))
+	}
+
+	if got := snapshots[0].Time.Unix(); got != 1752152700 {
+		t.Errorf("expected the epoch snapshot first, got %d", got)
+	}
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	if expected := time.Date(2025, 7, 10, 16, 5, 0, 0, madrid); !snapshots[1].Time.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, snapshots[1].Time)
+	}
+
+	t.Run("Missing time", func(t *testing.T) {
+		if _, err := ParseSnapshot([]byte(`{"location": {}}`), time.Time{}); err == nil {
+			t.Error("expected an error, but got nil")
+		}
+	})
+
+	t.Run("Given time", func(t *testing.T) {
+		at := time.Unix(1752152700, 0)
+		s, err := ParseSnapshot([]byte(`{"location": {}}`), at)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !s.Time.Equal(at) {
+			t.Errorf("expected %s, got %s", at, s.Time)
+		}
+	})
+}
+
+func TestNextHour(t *testing.T) {
+	var weather WeatherResponse
+	weather.Forecast.ForecastDay = append(weather.Forecast.ForecastDay, struct {
+		Date string `json:"date"`
+		Hour []Hour `json:"hour"`
+	}{Date: "2025-07-10", Hour: make([]Hour, 24)})
+	weather.Forecast.ForecastDay[0].Hour[15].ChanceOfRain = 80
+
+	hour, err := weather.NextHour(time.Date(2025, 7, 10, 14, 5, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hour.ChanceOfRain != 80 {
+		t.Errorf("expected the 15:00 hour, got %+v", hour)
+	}
+}
@@ -1,19 +1,25 @@
 package weather
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
 type WeatherResponse struct {
 	Location struct {
-		Name      string `json:"name"`
-		TzID      string `json:"tz_id"`
-		Localtime string `json:"localtime"`
+		Name      string  `json:"name"`
+		Lat       float64 `json:"lat"`
+		Lon       float64 `json:"lon"`
+		TzID      string  `json:"tz_id"`
+		Localtime string  `json:"localtime"`
+		// LocaltimeEpoch is when the forecast was issued, in unix seconds.
+		LocaltimeEpoch int64 `json:"localtime_epoch"`
 	} `json:"location"`
 
 	Forecast struct {
@@ -22,13 +28,34 @@ type WeatherResponse struct {
 			Hour []Hour `json:"hour"`
 		} `json:"forecastday"`
 	} `json:"forecast"`
+
+	Alerts struct {
+		Alert []Alert `json:"alert"`
+	} `json:"alerts"`
 }
 
 type Hour struct {
 	Time         string  `json:"time"`
+	IsDay        int     `json:"is_day"`
+	TempC        float64 `json:"temp_c"`
+	FeelsLikeC   float64 `json:"feelslike_c"`
+	HeatIndexC   float64 `json:"heatindex_c"`
+	UV           float64 `json:"uv"`
 	PrecipMM     float64 `json:"precip_mm"`
+	SnowCM       float64 `json:"snow_cm"`
 	WillItRain   int     `json:"will_it_rain"`
 	ChanceOfRain int     `json:"chance_of_rain"`
+	WillItSnow   int     `json:"will_it_snow"`
+	ChanceOfSnow int     `json:"chance_of_snow"`
+	WindKPH      float64 `json:"wind_kph"`
+	GustKPH      float64 `json:"gust_kph"`
+	WindDir      string  `json:"wind_dir"` // 16-point compass, e.g. "SW"
+	// AirQuality is only returned when requested.
+	AirQuality *AirQuality `json:"air_quality,omitempty"`
+	Condition  struct {
+		Text string `json:"text"`
+		Code int    `json:"code"`
+	} `json:"condition"`
 }
 
 type HTTPClient interface {
@@ -39,6 +66,8 @@ type API struct {
 	HttpClient HTTPClient
 	URL        string
 	ApiKey     string
+	// SearchURL is the search endpoint used by Search.
+	SearchURL string
 }
 
 func NewAPI(client HTTPClient, url, apiKey string) *API {
@@ -48,76 +77,91 @@ func NewAPI(client HTTPClient, url, apiKey string) *API {
 const (
 	checkAheadHours = 1
 	userAgent       = "rain-alert/1.0"
+	// MaxForecastDays is the longest forecast WeatherAPI returns.
+	MaxForecastDays = 14
 )
 
-func (a *API) GetNextHourForecast(location, timezone string) (*WeatherResponse, *Hour, error) {
-	weather, err := a.fetchWeather(location)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	hour, err := a.getNextHourForecast(weather, timezone)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return weather, hour, nil
+// GetForecast fetches a forecast of the given number of days, starting
+// today. With airQuality, hours include their air quality.
+func (a *API) GetForecast(ctx context.Context, location string, days int, airQuality bool) (*WeatherResponse, error) {
+	return a.fetchWeather(ctx, location, days, airQuality)
 }
 
-func (a *API) fetchWeather(location string) (*WeatherResponse, error) {
+func (a *API) fetchWeather(ctx context.Context, location string, days int, airQuality bool) (*WeatherResponse, error) {
+	days = min(max(days, 1), MaxForecastDays)
+
 	params := url.Values{}
 	params.Set("key", a.ApiKey)
 	params.Set("q", location)
-	params.Set("days", "1")
+	params.Set("days", strconv.Itoa(days))
 	params.Set("aqi", "no")
-	params.Set("alerts", "no")
+	if airQuality {
+		params.Set("aqi", "yes")
+	}
+	params.Set("alerts", "yes")
+
+	var weather WeatherResponse
+	if err := a.get(ctx, a.URL, params, &weather); err != nil {
+		return nil, err
+	}
+
+	return &weather, nil
+}
 
-	fullURL := fmt.Sprintf("%s?%s", a.URL, params.Encode())
-	req, err := http.NewRequest("GET", fullURL, nil)
+// get requests an endpoint and decodes its JSON response into v.
+func (a *API) get(ctx context.Context, endpoint string, params url.Values, v any) error {
+	fullURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return fmt.Errorf("creating request: %w", err)
 	}
 	req.Header.Set("User-Agent", userAgent)
 
 	resp, err := a.HttpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("making request: %w", err)
+		return fmt.Errorf("making request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+		return fmt.Errorf("unexpected response: %s", resp.Status)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
+		return fmt.Errorf("reading response body: %w", err)
 	}
 
-	var weather WeatherResponse
-	if err := json.Unmarshal(body, &weather); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
 	}
+	return nil
+}
 
-	return &weather, nil
+// Timezone returns the location's timezone, as reported by the provider.
+func (w *WeatherResponse) Timezone() (*time.Location, error) {
+	if w.Location.TzID == "" {
+		return nil, fmt.Errorf("no timezone in the forecast")
+	}
+	tz, err := time.LoadLocation(w.Location.TzID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return tz, nil
 }
 
-func (a *API) getNextHourForecast(weather *WeatherResponse, timezone string) (*Hour, error) {
-	if len(weather.Forecast.ForecastDay) == 0 {
+// NextHour returns the forecast of the hour after now, which must be in the
+// location's timezone.
+func (w *WeatherResponse) NextHour(now time.Time) (*Hour, error) {
+	if len(w.Forecast.ForecastDay) == 0 {
 		return nil, fmt.Errorf("no forecast days found")
 	}
 
-	hours := weather.Forecast.ForecastDay[0].Hour
+	hours := w.Forecast.ForecastDay[0].Hour
 	if len(hours) < 24 {
 		return nil, fmt.Errorf("hourly forecast incomplete")
 	}
 
-	tz, err := time.LoadLocation(timezone)
-	if err != nil {
-		return nil, fmt.Errorf("invalid timezone: %w", err)
-	}
-	nowIn := time.Now().In(tz)
-
-	nextHour := (nowIn.Hour() + checkAheadHours) % 24 // if 23 check 0, hours starts at 00
-	return &weather.Forecast.ForecastDay[0].Hour[nextHour], nil
+	nextHour := (now.Hour() + checkAheadHours) % 24 // if 23 check 0, hours starts at 00
+	return &hours[nextHour], nil
 }
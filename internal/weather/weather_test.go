@@ -1,13 +1,13 @@
-
 package weather
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -31,41 +31,19 @@ func NewMockClient(statusCode int, body string) *MockClient {
 	}
 }
 
-func TestGetNextHourForecast(t *testing.T) {
+func TestGetForecast(t *testing.T) {
 	t.Run("Successful forecast retrieval", func(t *testing.T) {
-		weatherResponse := &WeatherResponse{
-			Forecast: struct {
-				ForecastDay []struct {
-					Date string `json:"date"`
-					Hour []Hour `json:"hour"`
-				} `json:"forecastday"`
-			}{
-				ForecastDay: []struct {
-					Date string `json:"date"`
-					Hour []Hour `json:"hour"`
-				}{
-					{
-						Date: "2025-07-10",
-						Hour: make([]Hour, 24),
-					},
-				},
-			},
-		}
-		nextHour := (time.Now().In(time.UTC).Hour() + 1) % 24
-		weatherResponse.Forecast.ForecastDay[0].Hour[nextHour] = Hour{ChanceOfRain: 80}
-
-		weatherBody, _ := json.Marshal(weatherResponse)
-		mockClient := NewMockClient(http.StatusOK, string(weatherBody))
+		body := `{"location": {"name": "Test Location", "tz_id": "UTC"}, "forecast": {"forecastday": [{"date": "2025-07-10", "hour": [{"time": "2025-07-10 14:00", "chance_of_rain": 80}]}]}}`
+		api := NewAPI(NewMockClient(http.StatusOK, body), "http://test.com", "test-key")
 
-		api := NewAPI(mockClient, "http://test.com", "test-key")
-
-		_, hour, err := api.GetNextHourForecast("Test Location", "UTC")
+		weather, err := api.GetForecast(context.Background(), "Test Location", 1, false)
 		if err != nil {
-			t.Errorf("unexpected error: %v", err)
+			t.Fatalf("unexpected error: %v", err)
 		}
 
-		if hour.ChanceOfRain != 80 {
-			t.Errorf("expected chance of rain to be 80, got %d", hour.ChanceOfRain)
+		hours := weather.Forecast.ForecastDay[0].Hour
+		if len(hours) != 1 || hours[0].ChanceOfRain != 80 {
+			t.Errorf("expected one hour with 80%% chance of rain, got %+v", hours)
 		}
 	})
 
@@ -77,11 +55,54 @@ func TestGetNextHourForecast(t *testing.T) {
 		}
 		api := NewAPI(mockClient, "http://test.com", "test-key")
 
-		_, _, err := api.GetNextHourForecast("Test Location", "UTC")
+		_, err := api.GetForecast(context.Background(), "Test Location", 1, false)
 		if err == nil {
 			t.Error("expected an error, but got nil")
 		}
 	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		mockClient := &MockClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return nil, req.Context().Err()
+			},
+		}
+		api := NewAPI(mockClient, "http://test.com", "test-key")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := api.GetForecast(ctx, "Test Location", 1, false)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the request to be cancelled, got %v", err)
+		}
+	})
+}
+
+func TestGetForecastDays(t *testing.T) {
+	for _, tt := range []struct{ days, expected int }{{0, 1}, {7, 7}, {30, MaxForecastDays}} {
+		var days, alerts, aqi string
+		mockClient := &MockClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				days = req.URL.Query().Get("days")
+				alerts = req.URL.Query().Get("alerts")
+				aqi = req.URL.Query().Get("aqi")
+				return nil, errors.New("stop")
+			},
+		}
+		api := NewAPI(mockClient, "http://test.com", "test-key")
+
+		api.GetForecast(context.Background(), "Test Location", tt.days, false)
+
+		if days != strconv.Itoa(tt.expected) {
+			t.Errorf("expected days=%d for %d, got %s", tt.expected, tt.days, days)
+		}
+		if alerts != "yes" {
+			t.Errorf("expected alerts to be requested, got %q", alerts)
+		}
+		if aqi != "no" {
+			t.Errorf("expected no air quality, got %q", aqi)
+		}
+	}
 }
 
 func TestMain(m *testing.M) {
@@ -0,0 +1,79 @@
+package weather
+
+import "time"
+
+// TimeLayout is the layout of forecast times such as "2025-07-10 14:00".
+const TimeLayout = "2006-01-02 15:04"
+
+// Window is a run of consecutive forecast hours where rain is likely.
+type Window struct {
+	Start     string  `json:"start"`
+	End       string  `json:"end"`
+	MaxChance int     `json:"max_chance"`
+	TotalMM   float64 `json:"total_mm"`
+}
+
+// RainWindows groups consecutive hours whose chance of rain is at least
+// minChance into windows. End is the time of the last rainy hour.
+func RainWindows(hours []Hour, minChance int) []Window {
+	var windows []Window
+	var current *Window
+
+	for _, h := range hours {
+		if h.ChanceOfRain < minChance {
+			current = nil
+			continue
+		}
+		if current == nil {
+			windows = append(windows, Window{Start: h.Time})
+			current = &windows[len(windows)-1]
+		}
+		current.End = h.Time
+		current.TotalMM += h.PrecipMM
+		if h.ChanceOfRain > current.MaxChance {
+			current.MaxChance = h.ChanceOfRain
+		}
+	}
+
+	return windows
+}
+
+// HoursBetween returns the forecast hours overlapping [from, to), across all
+// forecast days. Forecast times are read in from's location.
+func (w *WeatherResponse) HoursBetween(from, to time.Time) []Hour {
+	var hours []Hour
+	for _, day := range w.Forecast.ForecastDay {
+		for _, h := range day.Hour {
+			start, err := time.ParseInLocation(TimeLayout, h.Time, from.Location())
+			if err != nil {
+				continue
+			}
+			if start.Before(to) && start.Add(time.Hour).After(from) {
+				hours = append(hours, h)
+			}
+		}
+	}
+	return hours
+}
+
+// Summary condenses a run of forecast hours.
+type Summary struct {
+	Windows   []Window
+	TotalMM   float64
+	MaxChance int
+	Peak      Hour // hour with the most precipitation
+}
+
+// Summarize totals the precipitation of the hours and groups the ones with a
+// chance of rain of at least minChance into windows.
+func Summarize(hours []Hour, minChance int) Summary {
+	s := Summary{Windows: RainWindows(hours, minChance)}
+	for i, h := range hours {
+		s.TotalMM += h.PrecipMM
+		s.MaxChance = max(s.MaxChance, h.ChanceOfRain)
+		if i == 0 || h.PrecipMM > s.Peak.PrecipMM {
+			s.Peak = h
+		}
+	}
+	return s
+}
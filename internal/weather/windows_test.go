@@ -0,0 +1,87 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRainWindows(t *testing.T) {
+	hours := []Hour{
+		{Time: "2025-07-10 08:00", ChanceOfRain: 10},
+		{Time: "2025-07-10 09:00", ChanceOfRain: 60, PrecipMM: 0.5},
+		{Time: "2025-07-10 10:00", ChanceOfRain: 80, PrecipMM: 1.5},
+		{Time: "2025-07-10 11:00", ChanceOfRain: 20},
+		{Time: "2025-07-10 12:00", ChanceOfRain: 55, PrecipMM: 0.2},
+	}
+
+	windows := RainWindows(hours, 50)
+	if len(windows) != 2 {
+		t.Fatalf("expected 2 windows, got %d", len(windows))
+	}
+
+	first := windows[0]
+	if first.Start != "2025-07-10 09:00" || first.End != "2025-07-10 10:00" {
+		t.Errorf("expected first window 09:00-10:00, got %s-%s", first.Start, first.End)
+	}
+	if first.MaxChance != 80 {
+		t.Errorf("expected max chance 80, got %d", first.MaxChance)
+	}
+	if first.TotalMM != 2.0 {
+		t.Errorf("expected total 2.0mm, got %.2f", first.TotalMM)
+	}
+
+	if windows[1].Start != windows[1].End {
+		t.Errorf("expected single hour window, got %s-%s", windows[1].Start, windows[1].End)
+	}
+}
+
+func TestHoursBetween(t *testing.T) {
+	var w WeatherResponse
+	w.Forecast.ForecastDay = append(w.Forecast.ForecastDay, struct {
+		Date string `json:"date"`
+		Hour []Hour `json:"hour"`
+	}{
+		Date: "2025-07-10",
+		Hour: []Hour{
+			{Time: "2025-07-10 07:00"},
+			{Time: "2025-07-10 08:00"},
+			{Time: "2025-07-10 09:00"},
+			{Time: "2025-07-10 17:00"},
+			{Time: "2025-07-10 18:00"},
+		},
+	})
+
+	morning := w.HoursBetween(time.Date(2025, 7, 10, 8, 15, 0, 0, time.UTC), time.Date(2025, 7, 10, 8, 45, 0, 0, time.UTC))
+	if len(morning) != 1 || morning[0].Time != "2025-07-10 08:00" {
+		t.Errorf("expected the 08:00 hour, got %v", morning)
+	}
+
+	evening := w.HoursBetween(time.Date(2025, 7, 10, 17, 30, 0, 0, time.UTC), time.Date(2025, 7, 10, 18, 15, 0, 0, time.UTC))
+	if len(evening) != 2 {
+		t.Errorf("expected 17:00 and 18:00, got %v", evening)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	hours := []Hour{
+		{Time: "2025-07-10 08:00", ChanceOfRain: 10},
+		{Time: "2025-07-10 09:00", ChanceOfRain: 60, PrecipMM: 0.5},
+		{Time: "2025-07-10 10:00", ChanceOfRain: 80, PrecipMM: 1.5},
+		{Time: "2025-07-10 11:00", ChanceOfRain: 20, PrecipMM: 0.1},
+	}
+
+	s := Summarize(hours, 50)
+
+	if len(s.Windows) != 1 {
+		t.Errorf("expected 1 window, got %d", len(s.Windows))
+	}
+	if s.TotalMM < 2.09 || s.TotalMM > 2.11 {
+		t.Errorf("expected total 2.1mm, got %.2f", s.TotalMM)
+	}
+	if s.MaxChance != 80 {
+		t.Errorf("expected max chance 80, got %d", s.MaxChance)
+	}
+	if s.Peak.Time != "2025-07-10 10:00" {
+		t.Errorf("expected peak at 10:00, got %s", s.Peak.Time)
+	}
+}